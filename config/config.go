@@ -0,0 +1,225 @@
+// Package config loads the settings shared by the gRPC servers and clients
+// in this repository.
+//
+// Values are taken, from lowest to highest precedence, from the defaults,
+// a YAML file, environment variables and command line flags. The YAML file
+// is named by the -config flag or the CONFIG_FILE environment variable.
+// Every setting has a flag named after its YAML path, e.g.
+// -storage.mongo.database, and most have an environment variable listed
+// in the env tag of its field.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Server  ServerConfig  `yaml:"server"`
+	Client  ClientConfig  `yaml:"client"`
+	Storage StorageConfig `yaml:"storage"`
+	Log     LogConfig     `yaml:"log"`
+}
+
+type ServerConfig struct {
+	Address         string        `yaml:"address" env:"LISTEN_ADDRESS" usage:"address the server listens on"`
+	TLS             TLSConfig     `yaml:"tls" env:"SERVER_TLS"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" usage:"how long in-flight RPCs may drain on shutdown"`
+	DebugAddress    string        `yaml:"debug_address" env:"DEBUG_ADDRESS" usage:"address of the HTTP listener serving /debug/vars, empty disables it"`
+	DefaultTenant   string        `yaml:"default_tenant" env:"DEFAULT_TENANT" usage:"tenant of product calls without a tenant-id header, empty makes the header required"`
+}
+
+type ClientConfig struct {
+	Address string        `yaml:"address" env:"SERVER_ADDRESS" usage:"address of the server to call"`
+	TLS     TLSConfig     `yaml:"tls" env:"CLIENT_TLS"`
+	Timeout time.Duration `yaml:"timeout" env:"CLIENT_TIMEOUT" usage:"deadline of a single call"`
+	Tenant  string        `yaml:"tenant" env:"CLIENT_TENANT" usage:"tenant-id sent with product calls"`
+}
+
+// TLSConfig is used by servers and clients alike. A server needs a
+// certificate and key and verifies client certificates against CAFile if
+// set. A client verifies the server against CAFile (or the system pool)
+// and presents a certificate if one is set.
+type TLSConfig struct {
+	Enabled    bool   `yaml:"enabled" env:"_ENABLED" usage:"use TLS"`
+	CertFile   string `yaml:"cert_file" env:"_CERT_FILE" usage:"PEM certificate"`
+	KeyFile    string `yaml:"key_file" env:"_KEY_FILE" usage:"PEM private key"`
+	CAFile     string `yaml:"ca_file" env:"_CA_FILE" usage:"PEM CA bundle used to verify the peer"`
+	ServerName string `yaml:"server_name" env:"_SERVER_NAME" usage:"expected server name, clients only"`
+}
+
+type StorageConfig struct {
+	Backend           string           `yaml:"backend" env:"STORAGE_BACKEND" usage:"product storage backend: mongo or memory"`
+	Mongo             MongoConfig      `yaml:"mongo"`
+	MigrationsDryRun  bool             `yaml:"migrations_dry_run" env:"MIGRATIONS_DRY_RUN" usage:"only report pending migrations and exit"`
+	MigrationsTimeout time.Duration    `yaml:"migrations_timeout" env:"MIGRATIONS_TIMEOUT" usage:"deadline of the startup migration run, 0 for none"`
+	IdempotencyTTL    time.Duration    `yaml:"idempotency_ttl" env:"IDEMPOTENCY_TTL" usage:"how long idempotency keys are remembered"`
+	Cache             CacheConfig      `yaml:"cache"`
+	Assets            AssetsConfig     `yaml:"assets"`
+	PriceInterval     time.Duration    `yaml:"price_interval" env:"PRICE_SCHEDULER_INTERVAL" usage:"how often scheduled price changes are applied"`
+	DeletedRetention  time.Duration    `yaml:"deleted_retention" env:"DELETED_RETENTION" usage:"how long deleted products can be restored before they are purged"`
+	PurgeInterval     time.Duration    `yaml:"purge_interval" env:"PURGE_INTERVAL" usage:"how often deleted products are purged"`
+	IDs               IDConfig         `yaml:"ids"`
+	Duplicates        DuplicatesConfig `yaml:"duplicates"`
+}
+
+// DuplicatesConfig controls the duplicate check of AddProduct. Products
+// with the same normalized name, or a name and description similarity of
+// at least Threshold, are duplicates.
+type DuplicatesConfig struct {
+	Policy    string  `yaml:"policy" env:"DUPLICATE_POLICY" usage:"what AddProduct does with duplicates: off, warn or reject"`
+	Threshold float64 `yaml:"threshold" env:"DUPLICATE_THRESHOLD" usage:"similarity from 0 to 1 from which products are duplicates"`
+}
+
+// IDConfig selects how product ids are made. uuidv7 and ulid ids sort in
+// creation order.
+type IDConfig struct {
+	Generator    string `yaml:"generator" env:"ID_GENERATOR" usage:"product id generator: uuidv4, uuidv7 or ulid"`
+	AcceptClient bool   `yaml:"accept_client" env:"ACCEPT_CLIENT_IDS" usage:"keep product ids sent by clients if they match the generator format"`
+}
+
+type MongoConfig struct {
+	URI        string        `yaml:"uri" env:"MONGODB_URI" usage:"MongoDB connection string"`
+	Database   string        `yaml:"database" env:"MONGODB_DATABASE" usage:"MongoDB database"`
+	Collection string        `yaml:"collection" env:"MONGODB_COLLECTION" usage:"MongoDB product collection"`
+	Timeout    time.Duration `yaml:"timeout" env:"MONGODB_TIMEOUT" usage:"MongoDB connect and server selection timeout"`
+}
+
+type CacheConfig struct {
+	Size int           `yaml:"size" env:"PRODUCT_CACHE_SIZE" usage:"product cache entries, 0 disables the cache"`
+	TTL  time.Duration `yaml:"ttl" env:"PRODUCT_CACHE_TTL" usage:"product cache entry lifetime"`
+}
+
+// AssetsConfig selects where product assets are kept. With an empty
+// backend GridFS is used together with the mongo storage backend and the
+// filesystem otherwise.
+type AssetsConfig struct {
+	Backend string `yaml:"backend" env:"ASSETS_BACKEND" usage:"product asset store: fs or gridfs"`
+	Dir     string `yaml:"dir" env:"ASSETS_DIR" usage:"directory of the fs asset store"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL" usage:"debug, info, warn or error"`
+	Format string `yaml:"format" env:"LOG_FORMAT" usage:"text or json"`
+}
+
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Address:         ":50051",
+			ShutdownTimeout: 30 * time.Second,
+			DefaultTenant:   "default",
+		},
+		Client: ClientConfig{
+			Address: "localhost:50051",
+			Timeout: time.Second,
+			Tenant:  "default",
+		},
+		Storage: StorageConfig{
+			Backend: "mongo",
+			Mongo: MongoConfig{
+				Database:   "fevse",
+				Collection: "storage",
+				Timeout:    10 * time.Second,
+			},
+			IdempotencyTTL:   24 * time.Hour,
+			PriceInterval:    30 * time.Second,
+			DeletedRetention: 30 * 24 * time.Hour,
+			PurgeInterval:    time.Hour,
+			Cache: CacheConfig{
+				TTL: time.Minute,
+			},
+			Assets: AssetsConfig{
+				Dir: "assets",
+			},
+			IDs: IDConfig{
+				Generator: "uuidv4",
+			},
+			Duplicates: DuplicatesConfig{
+				Policy:    "off",
+				Threshold: 0.9,
+			},
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "text",
+		},
+	}
+}
+
+// Load builds the configuration for the program name from args (without
+// the program name) and the environment. It returns the arguments left
+// after the flags.
+func Load(name string, args []string) (*Config, []string, error) {
+	cfg := Default()
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	file := fs.String("config", os.Getenv("CONFIG_FILE"), "YAML configuration file")
+	fields := settings(cfg)
+	// Flags are only recorded while parsing and applied last, since the
+	// configuration file they may name has to be read first.
+	var flagged []func() error
+	for _, s := range fields {
+		s := s
+		record := func(v string) error {
+			flagged = append(flagged, func() error {
+				if err := s.set(v); err != nil {
+					return fmt.Errorf("-%s: %w", s.flag, err)
+				}
+				return nil
+			})
+			return nil
+		}
+		if s.isBool() {
+			fs.BoolFunc(s.flag, s.usage, record)
+		} else {
+			fs.Func(s.flag, s.usage, record)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	if *file != "" {
+		if err := loadFile(cfg, *file); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, s := range fields {
+		if s.env == "" {
+			continue
+		}
+		if v, ok := os.LookupEnv(s.env); ok {
+			if err := s.set(v); err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", s.env, err)
+			}
+		}
+	}
+	for _, apply := range flagged {
+		if err := apply(); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := cfg.Log.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return cfg, fs.Args(), nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, args, err := Load("test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(args) != 0 {
+		t.Fatalf("got args %v", args)
+	}
+	if cfg.Server.Address != ":50051" || cfg.Storage.Mongo.Database != "fevse" || cfg.Storage.PurgeInterval != time.Hour {
+		t.Fatalf("got %+v, want the defaults", cfg)
+	}
+	if cfg.Storage.Duplicates.Policy != "off" {
+		t.Fatalf("got duplicate policy %q, want off", cfg.Storage.Duplicates.Policy)
+	}
+}
+
+func TestLoadExampleFile(t *testing.T) {
+	cfg, _, err := Load("test", []string{"-config", "config.example.yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Storage.Duplicates.Policy != "off" {
+		t.Fatalf("got duplicate policy %q from the example, want off", cfg.Storage.Duplicates.Policy)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, `
+server:
+  address: ":1000"
+  shutdown_timeout: 5s
+storage:
+  mongo:
+    database: from-file
+    collection: from-file
+  duplicates:
+    threshold: 0.5
+`)
+	t.Setenv("SHUTDOWN_TIMEOUT", "7s")
+	t.Setenv("MONGODB_DATABASE", "from-env")
+	t.Setenv("MONGODB_COLLECTION", "from-env")
+	t.Setenv("SERVER_TLS_ENABLED", "true")
+
+	cfg, args, err := Load("test", []string{
+		"-config", path,
+		"-storage.mongo.collection", "from-flag",
+		"-storage.cache.size=3",
+		"import", "products.jsonl",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name      string
+		got, want any
+	}{
+		{"default", cfg.Storage.Mongo.Timeout, 10 * time.Second},
+		{"file over default", cfg.Server.Address, ":1000"},
+		{"file float", cfg.Storage.Duplicates.Threshold, 0.5},
+		{"env over file", cfg.Server.ShutdownTimeout, 7 * time.Second},
+		{"env over file", cfg.Storage.Mongo.Database, "from-env"},
+		{"flag over env", cfg.Storage.Mongo.Collection, "from-flag"},
+		{"flag int", cfg.Storage.Cache.Size, 3},
+		{"prefixed env", cfg.Server.TLS.Enabled, true},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+	if strings.Join(args, " ") != "import products.jsonl" {
+		t.Fatalf("got args %v", args)
+	}
+}
+
+func TestLoadConfigFileFromEnv(t *testing.T) {
+	t.Setenv("CONFIG_FILE", writeFile(t, "client:\n  tenant: acme\n"))
+	cfg, _, err := Load("test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Client.Tenant != "acme" {
+		t.Fatalf("got tenant %q from CONFIG_FILE", cfg.Client.Tenant)
+	}
+}
+
+func TestLoadBoolFlagOverridesEnv(t *testing.T) {
+	t.Setenv("MIGRATIONS_DRY_RUN", "true")
+	cfg, _, err := Load("test", []string{"-storage.migrations_dry_run=false"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Storage.MigrationsDryRun {
+		t.Fatal("flag did not override the environment")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		env  map[string]string
+		args []string
+		want string
+	}{
+		{"unknown yaml field", nil, []string{"-config", writeFile(t, "server:\n  adress: x\n")}, "field adress not found"},
+		{"missing file", nil, []string{"-config", filepath.Join(t.TempDir(), "missing.yaml")}, "no such file"},
+		{"bad env", map[string]string{"SHUTDOWN_TIMEOUT": "soon"}, nil, "SHUTDOWN_TIMEOUT"},
+		{"bad flag", nil, []string{"-storage.cache.size", "many"}, "-storage.cache.size"},
+		{"unknown flag", nil, []string{"-no-such-flag"}, "no-such-flag"},
+		{"bad log level", map[string]string{"LOG_LEVEL": "loud"}, nil, "log.level"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			_, _, err := Load("test", tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("got error %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
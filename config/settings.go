@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setting is a single configurable value found in Config by reflection.
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	value reflect.Value
+}
+
+// settings lists the leaf fields of cfg. Flag names are the dotted YAML
+// paths. An env tag starting with "_" is appended to the env tag of the
+// enclosing struct field.
+func settings(cfg *Config) []setting {
+	var out []setting
+	var walk func(v reflect.Value, path, env string)
+	walk = func(v reflect.Value, path, env string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			if path != "" {
+				name = path + "." + name
+			}
+			e := f.Tag.Get("env")
+			if strings.HasPrefix(e, "_") {
+				e = env + e
+			}
+			fv := v.Field(i)
+			if fv.Kind() == reflect.Struct {
+				walk(fv, name, e)
+				continue
+			}
+			out = append(out, setting{flag: name, env: e, usage: f.Tag.Get("usage"), value: fv})
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem(), "", "")
+	return out
+}
+
+func (s setting) isBool() bool {
+	return s.value.Kind() == reflect.Bool
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func (s setting) set(v string) error {
+	switch {
+	case s.value.Type() == durationType:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		s.value.SetInt(int64(d))
+	case s.value.Kind() == reflect.String:
+		s.value.SetString(v)
+	case s.value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		s.value.SetBool(b)
+	case s.value.Kind() == reflect.Int:
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		s.value.SetInt(int64(n))
+	case s.value.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return err
+		}
+		s.value.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported setting type %s", s.value.Type())
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+func (c LogConfig) level() (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.ToUpper(c.Level))); err != nil {
+		return l, fmt.Errorf("unknown log.level %q", c.Level)
+	}
+	return l, nil
+}
+
+// Setup installs a default slog logger with the configured level and
+// format. Output of the standard log package is not filtered by level
+// and keeps its plain format, so no log.Printf or log.Fatalf message is
+// ever dropped.
+func (c LogConfig) Setup() {
+	c.setup(os.Stderr)
+}
+
+func (c LogConfig) setup(w io.Writer) {
+	level, _ := c.level()
+	opts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler = slog.NewTextHandler(w, opts)
+	if c.Format == "json" {
+		h = slog.NewJSONHandler(w, opts)
+	}
+	slog.SetDefault(slog.New(h))
+	// SetDefault routes the log package through h at Info level, which
+	// log.level=warn would silence.
+	log.SetOutput(w)
+	log.SetFlags(log.LstdFlags)
+}
+
+// ServerTLS returns the TLS configuration of a server, or nil when TLS is
+// disabled.
+func (c TLSConfig) ServerTLS() (*tls.Config, error) {
+	if !c.Enabled {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if c.CAFile != "" {
+		pool, err := loadPool(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+// ClientTLS returns the TLS configuration of a client, or nil when TLS is
+// disabled.
+func (c TLSConfig) ClientTLS() (*tls.Config, error) {
+	if !c.Enabled {
+		return nil, nil
+	}
+	cfg := &tls.Config{ServerName: c.ServerName, MinVersion: tls.VersionTLS12}
+	if c.CAFile != "" {
+		pool, err := loadPool(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+func loadPool(path string) (*x509.CertPool, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSetupKeepsLogUnfiltered(t *testing.T) {
+	defaultLogger, flags, out := slog.Default(), log.Flags(), log.Writer()
+	t.Cleanup(func() {
+		slog.SetDefault(defaultLogger)
+		log.SetOutput(out)
+		log.SetFlags(flags)
+	})
+
+	var buf bytes.Buffer
+	LogConfig{Level: "error", Format: "json"}.setup(&buf)
+	log.Printf("failed to open storage")
+	slog.Info("filtered")
+	slog.Error("shown", "err", "boom")
+
+	got := buf.String()
+	if !strings.Contains(got, "failed to open storage") {
+		t.Errorf("log output was dropped: %q", got)
+	}
+	if strings.Contains(got, "filtered") {
+		t.Errorf("slog output below the level was written: %q", got)
+	}
+	if !strings.Contains(got, `"msg":"shown"`) {
+		t.Errorf("slog output is not json: %q", got)
+	}
+}
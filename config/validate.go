@@ -0,0 +1,112 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+func (c ServerConfig) Validate() error {
+	var errs []error
+	if c.Address == "" {
+		errs = append(errs, errors.New("server.address is required"))
+	}
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("server.shutdown_timeout must be positive"))
+	}
+	if c.TLS.Enabled && (c.TLS.CertFile == "" || c.TLS.KeyFile == "") {
+		errs = append(errs, errors.New("server.tls needs cert_file and key_file"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c ClientConfig) Validate() error {
+	var errs []error
+	if c.Address == "" {
+		errs = append(errs, errors.New("client.address is required"))
+	}
+	if c.Timeout <= 0 {
+		errs = append(errs, errors.New("client.timeout must be positive"))
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		errs = append(errs, errors.New("client.tls cert_file and key_file must be set together"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c StorageConfig) Validate() error {
+	var errs []error
+	switch c.Backend {
+	case "memory":
+	case "mongo":
+		if c.Mongo.URI == "" {
+			errs = append(errs, errors.New("storage.mongo.uri is required, set your MONGODB_URI environment variable"))
+		}
+		if c.Mongo.Database == "" || c.Mongo.Collection == "" {
+			errs = append(errs, errors.New("storage.mongo.database and storage.mongo.collection are required"))
+		}
+		if c.Mongo.Timeout <= 0 {
+			errs = append(errs, errors.New("storage.mongo.timeout must be positive"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown storage.backend %q", c.Backend))
+	}
+	if c.MigrationsTimeout < 0 {
+		errs = append(errs, errors.New("storage.migrations_timeout must not be negative"))
+	}
+	if c.IdempotencyTTL <= 0 {
+		errs = append(errs, errors.New("storage.idempotency_ttl must be positive"))
+	}
+	if c.PriceInterval <= 0 {
+		errs = append(errs, errors.New("storage.price_interval must be positive"))
+	}
+	if c.DeletedRetention <= 0 {
+		errs = append(errs, errors.New("storage.deleted_retention must be positive"))
+	}
+	if c.PurgeInterval <= 0 {
+		errs = append(errs, errors.New("storage.purge_interval must be positive"))
+	}
+	switch c.IDs.Generator {
+	case "uuidv4", "uuidv7", "ulid":
+	default:
+		errs = append(errs, fmt.Errorf("unknown storage.ids.generator %q", c.IDs.Generator))
+	}
+	switch c.Duplicates.Policy {
+	case "off", "warn", "reject":
+	default:
+		errs = append(errs, fmt.Errorf("unknown storage.duplicates.policy %q", c.Duplicates.Policy))
+	}
+	if c.Duplicates.Threshold <= 0 || c.Duplicates.Threshold > 1 {
+		errs = append(errs, errors.New("storage.duplicates.threshold must be above 0 and at most 1"))
+	}
+	if c.Cache.Size < 0 {
+		errs = append(errs, errors.New("storage.cache.size must not be negative"))
+	}
+	if c.Cache.Size > 0 && c.Cache.TTL <= 0 {
+		errs = append(errs, errors.New("storage.cache.ttl must be positive"))
+	}
+	switch c.Assets.Backend {
+	case "", "fs":
+		if c.Assets.Backend == "fs" || c.Backend != "mongo" {
+			if c.Assets.Dir == "" {
+				errs = append(errs, errors.New("storage.assets.dir is required"))
+			}
+		}
+	case "gridfs":
+		if c.Backend != "mongo" {
+			errs = append(errs, errors.New("storage.assets.backend gridfs needs the mongo storage backend"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown storage.assets.backend %q", c.Assets.Backend))
+	}
+	return errors.Join(errs...)
+}
+
+func (c LogConfig) Validate() error {
+	if _, err := c.level(); err != nil {
+		return err
+	}
+	if c.Format != "text" && c.Format != "json" {
+		return fmt.Errorf("unknown log.format %q", c.Format)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func validStorage() StorageConfig {
+	s := Default().Storage
+	s.Mongo.URI = "mongodb://localhost:27017"
+	return s
+}
+
+func TestStorageValidate(t *testing.T) {
+	if err := validStorage().Validate(); err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+	for _, tt := range []struct {
+		name   string
+		modify func(*StorageConfig)
+		want   string
+	}{
+		{"backend", func(s *StorageConfig) { s.Backend = "sqlite" }, `unknown storage.backend "sqlite"`},
+		{"mongo uri", func(s *StorageConfig) { s.Mongo.URI = "" }, "storage.mongo.uri is required"},
+		{"mongo collection", func(s *StorageConfig) { s.Mongo.Collection = "" }, "storage.mongo.database and storage.mongo.collection are required"},
+		{"mongo timeout", func(s *StorageConfig) { s.Mongo.Timeout = 0 }, "storage.mongo.timeout must be positive"},
+		{"migrations timeout", func(s *StorageConfig) { s.MigrationsTimeout = -time.Second }, "storage.migrations_timeout must not be negative"},
+		{"idempotency ttl", func(s *StorageConfig) { s.IdempotencyTTL = 0 }, "storage.idempotency_ttl must be positive"},
+		{"price interval", func(s *StorageConfig) { s.PriceInterval = 0 }, "storage.price_interval must be positive"},
+		{"retention", func(s *StorageConfig) { s.DeletedRetention = 0 }, "storage.deleted_retention must be positive"},
+		{"purge interval", func(s *StorageConfig) { s.PurgeInterval = 0 }, "storage.purge_interval must be positive"},
+		{"id generator", func(s *StorageConfig) { s.IDs.Generator = "uuidv1" }, `unknown storage.ids.generator "uuidv1"`},
+		{"duplicate policy", func(s *StorageConfig) { s.Duplicates.Policy = "merge" }, `unknown storage.duplicates.policy "merge"`},
+		{"duplicate threshold", func(s *StorageConfig) { s.Duplicates.Threshold = 1.5 }, "storage.duplicates.threshold must be above 0 and at most 1"},
+		{"cache size", func(s *StorageConfig) { s.Cache.Size = -1 }, "storage.cache.size must not be negative"},
+		{"cache ttl", func(s *StorageConfig) { s.Cache.Size, s.Cache.TTL = 10, 0 }, "storage.cache.ttl must be positive"},
+		{"assets backend", func(s *StorageConfig) { s.Assets.Backend = "s3" }, `unknown storage.assets.backend "s3"`},
+		{"assets dir", func(s *StorageConfig) { s.Backend, s.Assets.Dir = "memory", "" }, "storage.assets.dir is required"},
+		{"gridfs without mongo", func(s *StorageConfig) { s.Backend, s.Assets.Backend = "memory", "gridfs" }, "storage.assets.backend gridfs needs the mongo storage backend"},
+	} {
+		s := validStorage()
+		tt.modify(&s)
+		if err := s.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestStorageValidateJoinsErrors(t *testing.T) {
+	s := validStorage()
+	s.Backend = "memory"
+	s.PriceInterval = 0
+	s.Cache.Size = -1
+	err := s.Validate()
+	if err == nil || !strings.Contains(err.Error(), "price_interval") || !strings.Contains(err.Error(), "cache.size") {
+		t.Fatalf("got %v, want both errors", err)
+	}
+}
+
+func TestServerValidate(t *testing.T) {
+	if err := Default().Server.Validate(); err != nil {
+		t.Fatalf("default server config: %v", err)
+	}
+	s := Default().Server
+	s.Address = ""
+	s.ShutdownTimeout = 0
+	s.TLS.Enabled = true
+	err := s.Validate()
+	for _, want := range []string{"server.address is required", "server.shutdown_timeout must be positive", "server.tls needs cert_file and key_file"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("got %v, want %q", err, want)
+		}
+	}
+}
+
+func TestClientValidate(t *testing.T) {
+	if err := Default().Client.Validate(); err != nil {
+		t.Fatalf("default client config: %v", err)
+	}
+	c := Default().Client
+	c.Address = ""
+	c.Timeout = 0
+	c.TLS.CertFile = "client.pem"
+	err := c.Validate()
+	for _, want := range []string{"client.address is required", "client.timeout must be positive", "client.tls cert_file and key_file must be set together"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("got %v, want %q", err, want)
+		}
+	}
+}
+
+func TestLogValidate(t *testing.T) {
+	for _, tt := range []struct {
+		cfg  LogConfig
+		want string
+	}{
+		{LogConfig{Level: "debug", Format: "json"}, ""},
+		{LogConfig{Level: "WARN", Format: "text"}, ""},
+		{LogConfig{Level: "trace", Format: "text"}, `unknown log.level "trace"`},
+		{LogConfig{Level: "info", Format: "xml"}, `unknown log.format "xml"`},
+	} {
+		err := tt.cfg.Validate()
+		if tt.want == "" && err != nil || tt.want != "" && (err == nil || err.Error() != tt.want) {
+			t.Errorf("%+v: got %v, want %q", tt.cfg, err, tt.want)
+		}
+	}
+}
@@ -6,9 +6,13 @@ import (
 	"io"
 	"log"
 	pb "orderService/client/orderService"
+	"os"
 	"time"
 
+	"config"
+
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	hwpb "google.golang.org/grpc/examples/helloworld/helloworld"
 	"google.golang.org/grpc/metadata"
@@ -16,10 +20,20 @@ import (
 	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
-const addr = "localhost:50051"
-
 func main() {
-	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()),
+	cfg, _, err := config.Load(os.Args[0], os.Args[1:])
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	if err := cfg.Client.Validate(); err != nil {
+		log.Fatalf("invalid config: %v", err)
+	}
+	cfg.Log.Setup()
+	creds, err := transportCredentials(cfg.Client.TLS)
+	if err != nil {
+		log.Fatalf("failed to load TLS config: %v", err)
+	}
+	conn, err := grpc.NewClient(cfg.Client.Address, grpc.WithTransportCredentials(creds),
 		grpc.WithUnaryInterceptor(orderUnaryClientInterceptor),
 		grpc.WithStreamInterceptor(clientStreamInterceptor),
 	)
@@ -76,7 +90,7 @@ func main() {
 
 	helloClient := hwpb.NewGreeterClient(conn)
 
-	hwcCtx, hwcCancel := context.WithTimeout(context.Background(), time.Second)
+	hwcCtx, hwcCancel := context.WithTimeout(context.Background(), cfg.Client.Timeout)
 	defer hwcCancel()
 
 	helloResponse, err := helloClient.SayHello(hwcCtx, &hwpb.HelloRequest{Name: "Check!!! Check!!!"})
@@ -158,7 +172,8 @@ func main() {
 
 	// cancel
 
-	ctx, _ := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
 	streamProcOrder, err := client.ProcessOrders(ctx)
 	if err != nil {
@@ -193,6 +208,17 @@ func main() {
 	<-ch
 }
 
+func transportCredentials(cfg config.TLSConfig) (credentials.TransportCredentials, error) {
+	tlsCfg, err := cfg.ClientTLS()
+	if err != nil {
+		return nil, err
+	}
+	if tlsCfg == nil {
+		return insecure.NewCredentials(), nil
+	}
+	return credentials.NewTLS(tlsCfg), nil
+}
+
 func asncClientBidirectionalRPC(streamProcOrder pb.OrderManagement_ProcessOrdersClient, c chan bool) {
 	for {
 		combinedShipment, errProcOrder := streamProcOrder.Recv()
@@ -14,3 +14,9 @@ require (
 	golang.org/x/text v0.22.0 // indirect
 	google.golang.org/grpc/examples v0.0.0-20250328164711-5edab9e55414
 )
+
+require config v0.0.0
+
+require gopkg.in/yaml.v3 v3.0.1 // indirect
+
+replace config => ../config
@@ -8,21 +8,26 @@ import (
 	"log"
 	"net"
 	pb "orderService/service/orderService"
+	"os"
 	"strings"
 	"time"
 
+	"config"
+
 	epb "google.golang.org/genproto/googleapis/rpc/errdetails"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	hello_pb "google.golang.org/grpc/examples/helloworld/helloworld"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
-const port = ":50051"
 const orderBatchSize = 3
 
 var orderMap = make(map[string]*pb.Order, 0)
@@ -230,17 +235,39 @@ func orederStreamServerInterceptor(srv interface{}, stream grpc.ServerStream, in
 }
 
 func main() {
-	initSampleData()
-	lis, err := net.Listen("tcp", port)
+	cfg, _, err := config.Load(os.Args[0], os.Args[1:])
 	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
+		log.Fatalf("failed to load config: %v", err)
+	}
+	if err := cfg.Server.Validate(); err != nil {
+		log.Fatalf("invalid config: %v", err)
 	}
-	s := grpc.NewServer(
+	cfg.Log.Setup()
+	opts := []grpc.ServerOption{
 		grpc.UnaryInterceptor(orderUnaryServerInterceptor),
 		grpc.StreamInterceptor(orederStreamServerInterceptor),
-	)
+	}
+	tlsCfg, err := cfg.Server.TLS.ServerTLS()
+	if err != nil {
+		log.Fatalf("failed to load TLS config: %v", err)
+	}
+	if tlsCfg != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsCfg)))
+	}
+
+	initSampleData()
+	lis, err := net.Listen("tcp", cfg.Server.Address)
+	if err != nil {
+		log.Fatalf("failed to listen: %v", err)
+	}
+	s := grpc.NewServer(opts...)
 	pb.RegisterOrderManagementServer(s, &server{orderMap: orderMap})
 	hello_pb.RegisterGreeterServer(s, &helloServer{})
+	hs := health.NewServer()
+	hs.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	hs.SetServingStatus(pb.OrderManagement_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	hs.SetServingStatus(hello_pb.Greeter_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s, hs)
 	reflection.Register(s)
 	if err := s.Serve(lis); err != nil {
 		log.Fatalf("failed to serve: %v", err)
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	pb "client/sappgrpc"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// importProducts streams the products from a JSONL or CSV file to the
+// ImportProducts RPC. CSV files need a header row with name and
+// description columns.
+func importProducts(ctx context.Context, c pb.ProductInfoClient, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var read func(io.Reader, func(*pb.Product) error) error
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".jsonl", ".ndjson":
+		read = readJSONL
+	case ".csv":
+		read = readCSV
+	default:
+		return fmt.Errorf("unsupported import file type %q", ext)
+	}
+
+	stream, err := c.ImportProducts(ctx)
+	if err != nil {
+		return err
+	}
+	if err := read(f, stream.Send); err != nil {
+		stream.CloseSend()
+		return err
+	}
+	summary, err := stream.CloseAndRecv()
+	if err != nil {
+		return err
+	}
+	log.Printf("Import: received %d, imported %d, failed %d", summary.Received, summary.Imported, summary.Failed)
+	for _, e := range summary.Errors {
+		log.Printf("  row %d (%s): %s", e.Row, e.Name, e.Message)
+	}
+	return nil
+}
+
+func readJSONL(r io.Reader, send func(*pb.Product) error) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		var p pb.Product
+		if err := protojson.Unmarshal([]byte(text), &p); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := send(&p); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+func readCSV(r io.Reader, send func(*pb.Product) error) error {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return fmt.Errorf("read csv header: %w", err)
+	}
+	nameCol, descCol := -1, -1
+	for i, h := range header {
+		switch strings.ToLower(strings.TrimSpace(h)) {
+		case "name":
+			nameCol = i
+		case "description":
+			descCol = i
+		}
+	}
+	if nameCol < 0 {
+		return fmt.Errorf("csv header has no name column")
+	}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		p := &pb.Product{Name: rec[nameCol]}
+		if descCol >= 0 {
+			p.Description = rec[descCol]
+		}
+		if err := send(p); err != nil {
+			return err
+		}
+	}
+}
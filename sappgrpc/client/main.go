@@ -3,34 +3,65 @@ package main
 import (
 	"context"
 	"log"
+	"os"
 	"time"
 
 	pb "client/sappgrpc"
+	"config"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 )
 
-const address = "localhost:50051"
-
 func main() {
-	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	cfg, args, err := config.Load(os.Args[0], os.Args[1:])
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	if err := cfg.Client.Validate(); err != nil {
+		log.Fatalf("invalid config: %v", err)
+	}
+	cfg.Log.Setup()
+	creds, err := transportCredentials(cfg.Client.TLS)
+	if err != nil {
+		log.Fatalf("failed to load TLS config: %v", err)
+	}
+	conn, err := grpc.NewClient(cfg.Client.Address, grpc.WithTransportCredentials(creds))
 	if err != nil {
 		log.Fatalf("did not connect: %v", err)
 	}
 	defer conn.Close()
 	c := pb.NewProductInfoClient(conn)
+	base := metadata.AppendToOutgoingContext(context.Background(), "tenant-id", cfg.Client.Tenant)
+
+	if len(args) > 0 && args[0] == "import" {
+		if len(args) != 2 {
+			log.Fatal("usage: client [flags] import <file.jsonl|file.csv>")
+		}
+		ctx, cancel := context.WithTimeout(base, 10*time.Minute)
+		defer cancel()
+		if err := importProducts(ctx, c, args[1]); err != nil {
+			log.Fatalf("could not import products: %v", err)
+		}
+		return
+	}
 
 	name := "product test name"
 	description := "product test description"
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	ctx, cancel := context.WithTimeout(base, cfg.Client.Timeout)
 	defer cancel()
 
-	r, err := c.AddProduct(ctx, &pb.Product{Name: name, Description: description})
+	var trailer metadata.MD
+	r, err := c.AddProduct(ctx, &pb.Product{Name: name, Description: description}, grpc.Trailer(&trailer))
 	if err != nil {
 		log.Fatalf("could not add product: %v", err)
 	}
 	log.Printf("Product ID: %s added successfully", r.Value)
+	if w := trailer.Get("warning"); len(w) > 0 {
+		log.Printf("Warning: %s", w[0])
+	}
 
 	product, err := c.GetProduct(ctx, &pb.ProductID{Value: r.Value})
 	if err != nil {
@@ -38,3 +69,14 @@ func main() {
 	}
 	log.Printf("Product: %s", product.String())
 }
+
+func transportCredentials(cfg config.TLSConfig) (credentials.TransportCredentials, error) {
+	tlsCfg, err := cfg.ClientTLS()
+	if err != nil {
+		return nil, err
+	}
+	if tlsCfg == nil {
+		return insecure.NewCredentials(), nil
+	}
+	return credentials.NewTLS(tlsCfg), nil
+}
@@ -0,0 +1,84 @@
+// Package healthcheck drives the gRPC health service from periodic
+// storage pings.
+package healthcheck
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	DefaultInterval = 5 * time.Second
+	DefaultTimeout  = 2 * time.Second
+)
+
+// Pinger reports whether a backend is reachable.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Checker pings the backend every Interval and marks the overall server
+// ("") and every listed service SERVING or NOT_SERVING accordingly.
+type Checker struct {
+	Health   *health.Server
+	Pinger   Pinger
+	Services []string
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+func New(hs *health.Server, p Pinger, services ...string) *Checker {
+	return &Checker{
+		Health:   hs,
+		Pinger:   p,
+		Services: services,
+		Interval: DefaultInterval,
+		Timeout:  DefaultTimeout,
+	}
+}
+
+// Run checks once right away and then every Interval until ctx is done.
+func (c *Checker) Run(ctx context.Context) {
+	t := time.NewTicker(c.Interval)
+	defer t.Stop()
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		st := c.check(ctx)
+		if st != last {
+			level := slog.LevelInfo
+			if st == healthpb.HealthCheckResponse_NOT_SERVING {
+				level = slog.LevelWarn
+			}
+			slog.Log(ctx, level, "Storage health changed", "status", st.String())
+			last = st
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (c *Checker) check(ctx context.Context) healthpb.HealthCheckResponse_ServingStatus {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+	st := healthpb.HealthCheckResponse_SERVING
+	if err := c.Pinger.Ping(ctx); err != nil {
+		if ctx.Err() != nil && ctx.Err() != context.DeadlineExceeded {
+			// The checker itself is being stopped.
+			return healthpb.HealthCheckResponse_UNKNOWN
+		}
+		slog.Debug("Storage ping failed", "err", err)
+		st = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	c.Health.SetServingStatus("", st)
+	for _, s := range c.Services {
+		c.Health.SetServingStatus(s, st)
+	}
+	return st
+}
@@ -0,0 +1,128 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type fakePinger struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (p *fakePinger) set(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+func (p *fakePinger) Ping(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// blockingPinger answers only when ctx is done.
+type blockingPinger struct{}
+
+func (blockingPinger) Ping(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func wantServing(t *testing.T, hs *health.Server, want healthpb.HealthCheckResponse_ServingStatus, services ...string) {
+	t.Helper()
+	for _, s := range services {
+		res, err := hs.Check(context.Background(), &healthpb.HealthCheckRequest{Service: s})
+		if err != nil {
+			t.Fatalf("check %q: %v", s, err)
+		}
+		if res.Status != want {
+			t.Fatalf("service %q is %s, want %s", s, res.Status, want)
+		}
+	}
+}
+
+func TestCheckFollowsPing(t *testing.T) {
+	hs := health.NewServer()
+	p := &fakePinger{}
+	c := New(hs, p, "sappgrpc.ProductInfo")
+
+	if st := c.check(context.Background()); st != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("got %s", st)
+	}
+	wantServing(t, hs, healthpb.HealthCheckResponse_SERVING, "", "sappgrpc.ProductInfo")
+
+	p.set(errors.New("server selection timeout"))
+	if st := c.check(context.Background()); st != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("got %s", st)
+	}
+	wantServing(t, hs, healthpb.HealthCheckResponse_NOT_SERVING, "", "sappgrpc.ProductInfo")
+
+	p.set(nil)
+	c.check(context.Background())
+	wantServing(t, hs, healthpb.HealthCheckResponse_SERVING, "", "sappgrpc.ProductInfo")
+}
+
+func TestCheckPingTimeout(t *testing.T) {
+	hs := health.NewServer()
+	c := New(hs, blockingPinger{}, "sappgrpc.ProductInfo")
+	c.Timeout = 10 * time.Millisecond
+	if st := c.check(context.Background()); st != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("got %s for a ping that timed out", st)
+	}
+	wantServing(t, hs, healthpb.HealthCheckResponse_NOT_SERVING, "", "sappgrpc.ProductInfo")
+}
+
+func TestCheckStoppedCheckerKeepsStatus(t *testing.T) {
+	hs := health.NewServer()
+	c := New(hs, blockingPinger{}, "sappgrpc.ProductInfo")
+	hs.SetServingStatus("sappgrpc.ProductInfo", healthpb.HealthCheckResponse_SERVING)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if st := c.check(ctx); st != healthpb.HealthCheckResponse_UNKNOWN {
+		t.Fatalf("got %s for a stopped checker", st)
+	}
+	wantServing(t, hs, healthpb.HealthCheckResponse_SERVING, "sappgrpc.ProductInfo")
+}
+
+func TestRunFlipsStatus(t *testing.T) {
+	hs := health.NewServer()
+	p := &fakePinger{}
+	c := New(hs, p, "sappgrpc.ProductInfo")
+	c.Interval = 5 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	waitFor := func(want healthpb.HealthCheckResponse_ServingStatus) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			res, err := hs.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "sappgrpc.ProductInfo"})
+			if err == nil && res.Status == want {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+		t.Fatalf("status never became %s", want)
+	}
+	waitFor(healthpb.HealthCheckResponse_SERVING)
+	p.set(errors.New("connection refused"))
+	waitFor(healthpb.HealthCheckResponse_NOT_SERVING)
+	p.set(nil)
+	waitFor(healthpb.HealthCheckResponse_SERVING)
+}
@@ -0,0 +1,115 @@
+// Package lifecycle runs a gRPC server until it is asked to shut down and
+// then stops it and releases the resources it used, in order.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+const DefaultCloseTimeout = 10 * time.Second
+
+type closer struct {
+	name string
+	fn   func(context.Context) error
+}
+
+// Manager owns a gRPC server and the resources behind it.
+type Manager struct {
+	Server *grpc.Server
+	// DrainTimeout is how long in-flight RPCs may run after a shutdown
+	// signal before they are cancelled.
+	DrainTimeout time.Duration
+	// CloseTimeout bounds each function registered with OnShutdown.
+	CloseTimeout time.Duration
+
+	closers []closer
+	drain   []func()
+}
+
+func New(s *grpc.Server, drainTimeout time.Duration) *Manager {
+	return &Manager{
+		Server:       s,
+		DrainTimeout: drainTimeout,
+		CloseTimeout: DefaultCloseTimeout,
+	}
+}
+
+// OnDrain registers fn to be called as soon as shutdown starts, before
+// in-flight RPCs are drained.
+func (m *Manager) OnDrain(fn func()) {
+	m.drain = append(m.drain, fn)
+}
+
+// OnShutdown registers fn to be called once the server has stopped.
+// Functions run in reverse order of registration, so resources are
+// released before the ones they depend on.
+func (m *Manager) OnShutdown(name string, fn func(context.Context) error) {
+	m.closers = append(m.closers, closer{name: name, fn: fn})
+}
+
+// Run serves lis until SIGINT or SIGTERM is received or serving fails,
+// then stops the server and runs the shutdown functions.
+func (m *Manager) Run(lis net.Listener) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- m.Server.Serve(lis)
+	}()
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+		slog.Info("Shutdown signal received, draining", "timeout", m.DrainTimeout)
+	case serveErr = <-errc:
+		if serveErr != nil {
+			serveErr = fmt.Errorf("serve: %w", serveErr)
+		}
+	}
+	for _, fn := range m.drain {
+		fn()
+	}
+	m.stopServer()
+	return errors.Join(serveErr, m.close())
+}
+
+// stopServer waits for in-flight RPCs up to DrainTimeout and then cancels
+// whatever is left.
+func (m *Manager) stopServer() {
+	done := make(chan struct{})
+	go func() {
+		m.Server.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(m.DrainTimeout):
+		slog.Warn("Drain timeout exceeded, cancelling remaining RPCs")
+		m.Server.Stop()
+		<-done
+	}
+}
+
+func (m *Manager) close() error {
+	var errs []error
+	for i := len(m.closers) - 1; i >= 0; i-- {
+		c := m.closers[i]
+		ctx, cancel := context.WithTimeout(context.Background(), m.CloseTimeout)
+		if err := c.fn(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("close %s: %w", c.name, err))
+		}
+		cancel()
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,135 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// listen returns a listener for m and a health client connected to it.
+func listen(t *testing.T, m *Manager) (net.Listener, healthpb.HealthClient) {
+	t.Helper()
+	healthpb.RegisterHealthServer(m.Server, health.NewServer())
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return lis, healthpb.NewHealthClient(conn)
+}
+
+// run runs m on lis until it returns.
+func run(t *testing.T, m *Manager, lis net.Listener, client healthpb.HealthClient) <-chan error {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Run(lis)
+	}()
+	// Run installs its signal handler before serving, so once a call
+	// succeeds a signal reaches Run instead of ending the test binary.
+	if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{}, grpc.WaitForReady(true)); err != nil {
+		t.Fatal(err)
+	}
+	return done
+}
+
+func signalShutdown(t *testing.T) {
+	t.Helper()
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func waitRun(t *testing.T, done <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return")
+		return nil
+	}
+}
+
+func TestRunShutdownOrder(t *testing.T) {
+	m := New(grpc.NewServer(), time.Second)
+	var mu sync.Mutex
+	var steps []string
+	step := func(s string) {
+		mu.Lock()
+		defer mu.Unlock()
+		steps = append(steps, s)
+	}
+	lis, client := listen(t, m)
+	m.OnDrain(func() {
+		// The server still answers while draining starts.
+		if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+			t.Errorf("check during drain: %v", err)
+		}
+		step("drain")
+	})
+	m.OnShutdown("storage", func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("shutdown function has no deadline")
+		}
+		step("storage")
+		return nil
+	})
+	m.OnShutdown("cache", func(context.Context) error {
+		step("cache")
+		return errors.New("flush failed")
+	})
+	done := run(t, m, lis, client)
+
+	signalShutdown(t)
+	err := waitRun(t, done)
+	if err == nil || !strings.Contains(err.Error(), "close cache: flush failed") {
+		t.Fatalf("got error %v, want the failed shutdown function", err)
+	}
+	want := []string{"drain", "cache", "storage"}
+	if strings.Join(steps, ",") != strings.Join(want, ",") {
+		t.Fatalf("got steps %v, want %v", steps, want)
+	}
+}
+
+func TestRunStopsAfterDrainTimeout(t *testing.T) {
+	const drain = 100 * time.Millisecond
+	m := New(grpc.NewServer(), drain)
+	lis, client := listen(t, m)
+	done := run(t, m, lis, client)
+
+	// A watch stays open until the server cancels it.
+	stream, err := client.Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	signalShutdown(t)
+	if err := waitRun(t, done); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < drain {
+		t.Fatalf("Run returned after %s, before the drain timeout", elapsed)
+	}
+	if _, err := stream.Recv(); err == nil {
+		t.Fatal("watch survived the shutdown")
+	}
+}
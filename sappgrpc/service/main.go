@@ -3,35 +3,203 @@ package main
 
 import (
 	"context"
-	"log"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
 	"net"
-	"time"
+	"net/http"
+	"os"
 
+	"config"
+	"service/healthcheck"
+	"service/lifecycle"
+	"service/migrations"
+	"service/pricing"
+	"service/retention"
 	pb "service/sappgrpc"
 	"service/storage"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
-const port = ":50051"
-
 func main() {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	stor := storage.NewProductService()
-	defer func() {
-		if err := stor.Disconnect(ctx); err != nil {
-			log.Fatalf("Failded to disconnect form MongoDB: %v", err)
+	cfg, _, err := config.Load(os.Args[0], os.Args[1:])
+	if err != nil {
+		fatal("failed to load config", err)
+	}
+	if err := errors.Join(cfg.Server.Validate(), cfg.Storage.Validate()); err != nil {
+		fatal("invalid config", err)
+	}
+	if t := cfg.Server.DefaultTenant; t != "" && !storage.ValidTenant(t) {
+		fatal("invalid config", fmt.Errorf("invalid server.default_tenant %q", t))
+	}
+	cfg.Log.Setup()
+
+	serverOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(storage.UnaryTenantInterceptor(cfg.Server.DefaultTenant)),
+		grpc.ChainStreamInterceptor(storage.StreamTenantInterceptor(cfg.Server.DefaultTenant)),
+	}
+	tlsCfg, err := cfg.Server.TLS.ServerTLS()
+	if err != nil {
+		fatal("failed to load TLS config", err)
+	}
+	if tlsCfg != nil {
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(tlsCfg)))
+	}
+
+	backend, err := newStore(cfg.Storage)
+	if err != nil {
+		fatal("failed to open storage", err)
+	}
+	lis, err := net.Listen("tcp", cfg.Server.Address)
+	if err != nil {
+		backend.Close(context.Background())
+		fatal("failed to listen", err)
+	}
+	s := grpc.NewServer(serverOpts...)
+	m := lifecycle.New(s, cfg.Server.ShutdownTimeout)
+	m.OnShutdown("storage", backend.Close)
+
+	if ms, ok := backend.(*storage.MongoStore); ok {
+		dryRun := cfg.Storage.MigrationsDryRun
+		// Backfills and index builds take as long as the catalog needs,
+		// so they are not bound by the connect timeout.
+		ctx, cancel := context.Background(), context.CancelFunc(func() {})
+		if t := cfg.Storage.MigrationsTimeout; t > 0 {
+			ctx, cancel = context.WithTimeout(ctx, t)
 		}
-	}()
-	lis, err := net.Listen("tcp", port)
+		_, err := migrations.NewRunner(ms.Coll, dryRun).Run(ctx)
+		cancel()
+		if err != nil || dryRun {
+			lis.Close()
+			backend.Close(context.Background())
+			if err != nil {
+				fatal("failed to migrate storage", err)
+			}
+			return
+		}
+	}
+
+	store := backend
+	var opts []storage.Option
+	if ids, ok := backend.(storage.IdempotencyStore); ok {
+		opts = append(opts, storage.WithIdempotency(ids, cfg.Storage.IdempotencyTTL))
+	}
+	if cs, ok := backend.(storage.CategoryStore); ok {
+		opts = append(opts, storage.WithCategories(cs))
+	}
+	if vs, ok := backend.(storage.VariantStore); ok {
+		opts = append(opts, storage.WithVariants(vs))
+	}
+	if ps, ok := backend.(storage.PriceStore); ok {
+		opts = append(opts, storage.WithPrices(ps))
+	}
+	if as, ok := backend.(storage.AuditStore); ok {
+		opts = append(opts, storage.WithAudit(as))
+	}
+	ids, err := storage.NewIDGenerator(cfg.Storage.IDs.Generator)
 	if err != nil {
-		log.Fatal("failed to listen: %w", err)
+		fatal("failed to configure product ids", err)
 	}
-	s := grpc.NewServer()
-	server := storage.NewProductService()
-	pb.RegisterProductInfoServer(s, server)
-	if err := s.Serve(lis); err != nil {
-		log.Fatal("failed to serve: %w", err)
+	opts = append(opts, storage.WithIDGenerator(ids))
+	if cfg.Storage.IDs.AcceptClient {
+		opts = append(opts, storage.WithClientIDs())
+	}
+	if d := cfg.Storage.Duplicates; d.Policy != "off" {
+		opts = append(opts, storage.WithDuplicateCheck(storage.DuplicatePolicy(d.Policy), d.Threshold))
+	}
+	blobs, err := newBlobStore(cfg.Storage, backend)
+	if err != nil {
+		fatal("failed to open asset storage", err)
+	}
+	opts = append(opts, storage.WithBlobStore(blobs))
+	if c := cfg.Storage.Cache; c.Size > 0 {
+		cache := storage.NewCachedStore(store, c.Size, c.TTL)
+		expvar.Publish("product_cache", expvar.Func(func() any { return cache.Stats() }))
+		store = cache
+	}
+	svc := storage.NewProductService(store, opts...)
+	pb.RegisterProductInfoServer(s, svc)
+
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(s, hs)
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	go healthcheck.New(hs, backend, pb.ProductInfo_ServiceDesc.ServiceName).Run(bgCtx)
+	go pricing.New(svc, cfg.Storage.PriceInterval).Run(bgCtx)
+	go retention.New(svc, cfg.Storage.DeletedRetention, cfg.Storage.PurgeInterval).Run(bgCtx)
+	m.OnDrain(func() {
+		stopBackground()
+		hs.Shutdown()
+	})
+	if addr := cfg.Server.DebugAddress; addr != "" {
+		if err := serveDebug(m, addr); err != nil {
+			fatal("failed to start debug listener", err)
+		}
+	}
+
+	slog.Info("ProductInfo server listening", "address", lis.Addr().String())
+	if err := m.Run(lis); err != nil {
+		fatal("server stopped with error", err)
+	}
+	slog.Info("server stopped")
+}
+
+// fatal logs err and exits, like log.Fatalf.
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
+}
+
+// serveDebug serves the expvar counters, registered on the default mux,
+// until the server shuts down.
+func serveDebug(m *lifecycle.Manager, addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Handler: http.DefaultServeMux}
+	go func() {
+		if err := srv.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("debug listener stopped", "err", err)
+		}
+	}()
+	m.OnShutdown("debug listener", srv.Shutdown)
+	slog.Info("debug listener started", "address", lis.Addr().String())
+	return nil
+}
+
+func newStore(cfg config.StorageConfig) (storage.ProductStore, error) {
+	switch cfg.Backend {
+	case "mongo":
+		return storage.NewMongoStore(storage.MongoOptions{
+			URI:        cfg.Mongo.URI,
+			Database:   cfg.Mongo.Database,
+			Collection: cfg.Mongo.Collection,
+			Timeout:    cfg.Mongo.Timeout,
+		})
+	case "memory":
+		return storage.NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
+
+func newBlobStore(cfg config.StorageConfig, backend storage.ProductStore) (storage.BlobStore, error) {
+	ms, isMongo := backend.(*storage.MongoStore)
+	switch cfg.Assets.Backend {
+	case "gridfs":
+		if !isMongo {
+			return nil, fmt.Errorf("gridfs asset storage needs the mongo backend")
+		}
+		return storage.NewGridFSBlobStore(ms.Coll.Database()), nil
+	case "":
+		if isMongo {
+			return storage.NewGridFSBlobStore(ms.Coll.Database()), nil
+		}
 	}
+	return storage.NewFSBlobStore(cfg.Assets.Dir)
 }
@@ -0,0 +1,383 @@
+// Package migrations applies versioned schema changes to the MongoDB
+// product collection and records which versions have been applied.
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"service/storage"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const historyCollection = "migrations"
+
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, coll *mongo.Collection) error
+}
+
+// All lists the migrations in the order they are applied. Versions must
+// only ever be appended.
+var All = []Migration{
+	{Version: 1, Description: "unique index on id", Up: uniqueIDIndex},
+	{Version: 2, Description: "text index on name and description", Up: textIndex},
+	{Version: 3, Description: "backfill schema_version and timestamps", Up: backfillSchema},
+	{Version: 4, Description: "enable change stream pre-images", Up: enablePreImages},
+	{Version: 5, Description: "idempotency key indexes", Up: idempotencyIndexes},
+	{Version: 6, Description: "backfill product versions", Up: backfillVersion},
+	{Version: 7, Description: "assign products to the default tenant", Up: backfillTenant},
+	{Version: 8, Description: "category and tag indexes", Up: categoryIndexes},
+	{Version: 9, Description: "variant indexes", Up: variantIndexes},
+	{Version: 10, Description: "price history indexes", Up: priceIndexes},
+	{Version: 11, Description: "soft delete and audit event indexes", Up: auditIndexes},
+	{Version: 12, Description: "product ids unique per tenant", Up: tenantIDIndex},
+}
+
+type record struct {
+	Collection  string    `bson:"collection"`
+	Version     int       `bson:"version"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+// Runner applies migrations to Coll and keeps the history in the
+// migrations collection of the same database. With DryRun set pending
+// migrations are only reported.
+type Runner struct {
+	Coll       *mongo.Collection
+	History    *mongo.Collection
+	Migrations []Migration
+	DryRun     bool
+}
+
+func NewRunner(coll *mongo.Collection, dryRun bool) *Runner {
+	return &Runner{
+		Coll:       coll,
+		History:    coll.Database().Collection(historyCollection),
+		Migrations: All,
+		DryRun:     dryRun,
+	}
+}
+
+// Pending returns the migrations that have not been applied yet.
+func (r *Runner) Pending(ctx context.Context) ([]Migration, error) {
+	cur, err := r.History.Find(ctx, bson.D{{Key: "collection", Value: r.Coll.Name()}})
+	if err != nil {
+		return nil, err
+	}
+	var applied []record
+	if err := cur.All(ctx, &applied); err != nil {
+		return nil, err
+	}
+	done := make(map[int]bool, len(applied))
+	for _, a := range applied {
+		done[a.Version] = true
+	}
+	var pending []Migration
+	for _, m := range r.Migrations {
+		if !done[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Run applies pending migrations in order and returns them. It stops at
+// the first failing migration.
+func (r *Runner) Run(ctx context.Context) ([]Migration, error) {
+	pending, err := r.Pending(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("read migration history: %w", err)
+	}
+	slog.Debug("Read migration history", "collection", r.Coll.Name(), "pending", len(pending))
+	if r.DryRun {
+		for _, m := range pending {
+			slog.Info("Migration would be applied", "version", m.Version, "description", m.Description)
+		}
+		return pending, nil
+	}
+	if len(pending) > 0 {
+		_, err := r.History.Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "collection", Value: 1}, {Key: "version", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create migration history index: %w", err)
+		}
+	}
+	for i, m := range pending {
+		if err := m.Up(ctx, r.Coll); err != nil {
+			return pending[:i], fmt.Errorf("migration %d (%s): %w", m.Version, m.Description, err)
+		}
+		_, err := r.History.InsertOne(ctx, record{
+			Collection:  r.Coll.Name(),
+			Version:     m.Version,
+			Description: m.Description,
+			AppliedAt:   time.Now().UTC(),
+		})
+		if err != nil {
+			return pending[:i], fmt.Errorf("record migration %d: %w", m.Version, err)
+		}
+		slog.Info("Migration applied", "version", m.Version, "description", m.Description)
+	}
+	return pending, nil
+}
+
+func uniqueIDIndex(ctx context.Context, coll *mongo.Collection) error {
+	_, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "id", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("id_unique"),
+	})
+	return err
+}
+
+func textIndex(ctx context.Context, coll *mongo.Collection) error {
+	_, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "name", Value: "text"}, {Key: "description", Value: "text"}},
+		Options: options.Index().
+			SetName("name_description_text").
+			SetWeights(bson.D{{Key: "name", Value: 2}, {Key: "description", Value: 1}}),
+	})
+	return err
+}
+
+// backfillSchema upgrades documents written before schema versioning.
+// Their real creation time is unknown, so the migration time is used.
+func backfillSchema(ctx context.Context, coll *mongo.Collection) error {
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.D{
+			{Key: "schema_version", Value: 1},
+			{Key: "created_at", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$created_at", "$$NOW"}}}},
+			{Key: "updated_at", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$updated_at", "$$NOW"}}}},
+		}}},
+	}
+	_, err := coll.UpdateMany(ctx, bson.D{{Key: "schema_version", Value: bson.D{{Key: "$exists", Value: false}}}}, update)
+	return err
+}
+
+// enablePreImages lets WatchProducts report which product a delete event
+// removed. Servers older than MongoDB 6.0 keep no pre-images, so there the
+// migration only warns and delete events carry no product.
+func enablePreImages(ctx context.Context, coll *mongo.Collection) error {
+	err := coll.Database().RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: coll.Name()},
+		{Key: "changeStreamPreAndPostImages", Value: bson.D{{Key: "enabled", Value: true}}},
+	}).Err()
+	if preImagesUnsupported(err) {
+		slog.Warn("The server does not support change stream pre-images, delete events will not carry the product", "err", err)
+		return nil
+	}
+	return err
+}
+
+// preImagesUnsupported reports whether collMod failed because the server
+// does not know the changeStreamPreAndPostImages option.
+func preImagesUnsupported(err error) bool {
+	var ce mongo.CommandError
+	if !errors.As(err, &ce) {
+		return false
+	}
+	switch ce.Name {
+	case "InvalidOptions", "IDLUnknownField":
+		return true
+	}
+	return ce.Code == 72 || ce.Code == 40415
+}
+
+func idempotencyIndexes(ctx context.Context, coll *mongo.Collection) error {
+	_, err := coll.Database().Collection(storage.IdempotencyCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	return err
+}
+
+// backfillVersion gives documents written before etags were introduced
+// the version a freshly added product starts with.
+func backfillVersion(ctx context.Context, coll *mongo.Collection) error {
+	_, err := coll.UpdateMany(ctx,
+		bson.D{{Key: "version", Value: bson.D{{Key: "$exists", Value: false}}}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "version", Value: 1}}}},
+	)
+	return err
+}
+
+// backfillTenant hands the products stored before multi-tenancy to
+// storage.DefaultTenant and indexes the lookups every query now starts
+// with.
+func backfillTenant(ctx context.Context, coll *mongo.Collection) error {
+	_, err := coll.UpdateMany(ctx,
+		bson.D{{Key: "tenant", Value: bson.D{{Key: "$exists", Value: false}}}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "tenant", Value: storage.DefaultTenant}}}},
+	)
+	if err != nil {
+		return err
+	}
+	_, err = coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "tenant", Value: 1}, {Key: "id", Value: 1}},
+		Options: options.Index().SetName("tenant_id"),
+	})
+	return err
+}
+
+func categoryIndexes(ctx context.Context, coll *mongo.Collection) error {
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tenant", Value: 1}, {Key: "category", Value: 1}}},
+		{Keys: bson.D{{Key: "tenant", Value: 1}, {Key: "tags", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = coll.Database().Collection(storage.CategoryCollectionName).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "tenant", Value: 1}, {Key: "path", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// variantIndexes enforces unique variant ids and SKUs unique per tenant.
+func variantIndexes(ctx context.Context, coll *mongo.Collection) error {
+	_, err := coll.Database().Collection(storage.VariantCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "tenant", Value: 1}, {Key: "sku", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "tenant", Value: 1}, {Key: "product_id", Value: 1}, {Key: "sku", Value: 1}}},
+	})
+	return err
+}
+
+func priceIndexes(ctx context.Context, coll *mongo.Collection) error {
+	_, err := coll.Database().Collection(storage.PriceCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "tenant", Value: 1}, {Key: "product_id", Value: 1}, {Key: "effective_at", Value: 1}}},
+		// Serves the scheduler looking for due changes.
+		{Keys: bson.D{{Key: "applied", Value: 1}, {Key: "effective_at", Value: 1}}},
+	})
+	return err
+}
+
+// auditIndexes serves the purge job looking for deleted products and
+// paging through the audit trail of a product.
+func auditIndexes(ctx context.Context, coll *mongo.Collection) error {
+	_, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "deleted_at", Value: 1}},
+		// Only soft deleted products have deleted_at.
+		Options: options.Index().SetSparse(true),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = coll.Database().Collection(storage.AuditCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "tenant", Value: 1}, {Key: "product_id", Value: 1}, {Key: "id", Value: 1}}},
+	})
+	return err
+}
+
+// tenantIDIndex makes product ids unique per tenant instead of across all
+// tenants. The non-unique tenant_id index of migration 7 is replaced by a
+// unique one before id_unique is dropped, so ids stay unique throughout.
+func tenantIDIndex(ctx context.Context, coll *mongo.Collection) error {
+	if err := dropIndex(ctx, coll, "tenant_id"); err != nil {
+		return err
+	}
+	_, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "tenant", Value: 1}, {Key: "id", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("tenant_id"),
+	})
+	if err != nil {
+		return err
+	}
+	if err := dropIndex(ctx, coll, "id_unique"); err != nil {
+		return err
+	}
+	return moveAssets(ctx, coll)
+}
+
+// dropIndex drops the index name unless it does not exist.
+func dropIndex(ctx context.Context, coll *mongo.Collection, name string) error {
+	err := coll.Indexes().DropOne(ctx, name)
+	var ce mongo.CommandError
+	if errors.As(err, &ce) && (ce.Name == "IndexNotFound" || ce.Code == 27) {
+		return nil
+	}
+	return err
+}
+
+// moveAssets renames the GridFS assets from product/name to
+// tenant/product/name, as asset keys now start with the tenant of their
+// product. Ids were unique until now, so the product of an asset is
+// found by id alone. Assets of purged products are left alone.
+func moveAssets(ctx context.Context, coll *mongo.Collection) error {
+	files := coll.Database().Collection(storage.AssetBucketName + ".files")
+	cur, err := files.Find(ctx, bson.D{}, options.Find().SetProjection(bson.D{{Key: "filename", Value: 1}}))
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+	tenants := make(map[string]string)
+	for cur.Next(ctx) {
+		var f struct {
+			ID   bson.ObjectID `bson:"_id"`
+			Name string        `bson:"filename"`
+		}
+		if err := cur.Decode(&f); err != nil {
+			return err
+		}
+		// Asset names have no slash, moved files have two.
+		if strings.Count(f.Name, "/") != 1 {
+			continue
+		}
+		productID, _, _ := strings.Cut(f.Name, "/")
+		tenant, ok := tenants[productID]
+		if !ok {
+			var p struct {
+				Tenant string `bson:"tenant"`
+			}
+			opts := options.FindOne().SetProjection(bson.D{{Key: "tenant", Value: 1}})
+			err := coll.FindOne(ctx, bson.D{{Key: "id", Value: productID}}, opts).Decode(&p)
+			if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+				return err
+			}
+			tenant = p.Tenant
+			tenants[productID] = tenant
+		}
+		if tenant == "" {
+			continue
+		}
+		_, err := files.UpdateOne(ctx,
+			bson.D{{Key: "_id", Value: f.ID}},
+			bson.D{{Key: "$set", Value: bson.D{{Key: "filename", Value: tenant + "/" + f.Name}}}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
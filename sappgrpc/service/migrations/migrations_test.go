@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func TestPreImagesUnsupported(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("connection refused"), false},
+		// MongoDB 5.0 and older.
+		{mongo.CommandError{Code: 72, Name: "InvalidOptions", Message: "unknown option to collMod: changeStreamPreAndPostImages"}, true},
+		{fmt.Errorf("collMod: %w", mongo.CommandError{Code: 40415, Name: "IDLUnknownField"}), true},
+		{mongo.CommandError{Code: 13, Name: "Unauthorized"}, false},
+	} {
+		if got := preImagesUnsupported(tt.err); got != tt.want {
+			t.Errorf("preImagesUnsupported(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestVersionsOnlyGrow(t *testing.T) {
+	for i, m := range All {
+		if m.Version != i+1 {
+			t.Fatalf("migration %d has version %d", i+1, m.Version)
+		}
+		if m.Up == nil || m.Description == "" {
+			t.Fatalf("migration %d is incomplete", m.Version)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Package pricing applies scheduled price changes in the background.
+package pricing
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+const DefaultInterval = 30 * time.Second
+
+// Applier applies the price changes that have become effective and
+// reports how many products got a new price.
+type Applier interface {
+	ApplyDuePrices(ctx context.Context) (int, error)
+}
+
+// Scheduler runs an Applier every Interval.
+type Scheduler struct {
+	Applier  Applier
+	Interval time.Duration
+}
+
+func New(a Applier, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Scheduler{Applier: a, Interval: interval}
+}
+
+// Run applies due changes once right away and then every Interval until
+// ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	t := time.NewTicker(s.Interval)
+	defer t.Stop()
+	for {
+		n, err := s.Applier.ApplyDuePrices(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("Failed to apply scheduled prices", "err", err)
+		}
+		level := slog.LevelDebug
+		if n > 0 {
+			level = slog.LevelInfo
+		}
+		slog.Log(ctx, level, "Applied scheduled prices", "products", n)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// Package retention purges deleted products once their retention window
+// has passed.
+package retention
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+const (
+	DefaultInterval  = time.Hour
+	DefaultRetention = 30 * 24 * time.Hour
+)
+
+// Store purges the products deleted more than retention ago and reports
+// how many were purged.
+type Store interface {
+	PurgeDeleted(ctx context.Context, retention time.Duration) (int, error)
+}
+
+// Purger runs a purge every Interval.
+type Purger struct {
+	Store     Store
+	Retention time.Duration
+	Interval  time.Duration
+}
+
+func New(s Store, retention, interval time.Duration) *Purger {
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Purger{Store: s, Retention: retention, Interval: interval}
+}
+
+// Run purges once right away and then every Interval until ctx is done.
+func (p *Purger) Run(ctx context.Context) {
+	t := time.NewTicker(p.Interval)
+	defer t.Stop()
+	for {
+		n, err := p.Store.PurgeDeleted(ctx, p.Retention)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("Failed to purge deleted products", "err", err)
+		}
+		level := slog.LevelDebug
+		if n > 0 {
+			level = slog.LevelInfo
+		}
+		slog.Log(ctx, level, "Purged deleted products", "count", n)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
 )
 
 // This is a compile-time assertion to ensure that this generated file
@@ -19,8 +20,37 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	ProductInfo_AddProduct_FullMethodName = "/sappgrpc.ProductInfo/addProduct"
-	ProductInfo_GetProduct_FullMethodName = "/sappgrpc.ProductInfo/getProduct"
+	ProductInfo_AddProduct_FullMethodName               = "/sappgrpc.ProductInfo/addProduct"
+	ProductInfo_GetProduct_FullMethodName               = "/sappgrpc.ProductInfo/getProduct"
+	ProductInfo_BatchGetProducts_FullMethodName         = "/sappgrpc.ProductInfo/batchGetProducts"
+	ProductInfo_UpdateProduct_FullMethodName            = "/sappgrpc.ProductInfo/updateProduct"
+	ProductInfo_DeleteProduct_FullMethodName            = "/sappgrpc.ProductInfo/deleteProduct"
+	ProductInfo_ListProducts_FullMethodName             = "/sappgrpc.ProductInfo/listProducts"
+	ProductInfo_ExportProducts_FullMethodName           = "/sappgrpc.ProductInfo/exportProducts"
+	ProductInfo_ImportProducts_FullMethodName           = "/sappgrpc.ProductInfo/importProducts"
+	ProductInfo_WatchProducts_FullMethodName            = "/sappgrpc.ProductInfo/watchProducts"
+	ProductInfo_SearchProducts_FullMethodName           = "/sappgrpc.ProductInfo/searchProducts"
+	ProductInfo_UploadProductAsset_FullMethodName       = "/sappgrpc.ProductInfo/uploadProductAsset"
+	ProductInfo_DownloadProductAsset_FullMethodName     = "/sappgrpc.ProductInfo/downloadProductAsset"
+	ProductInfo_AddProductTranslation_FullMethodName    = "/sappgrpc.ProductInfo/addProductTranslation"
+	ProductInfo_RemoveProductTranslation_FullMethodName = "/sappgrpc.ProductInfo/removeProductTranslation"
+	ProductInfo_CreateCategory_FullMethodName           = "/sappgrpc.ProductInfo/createCategory"
+	ProductInfo_GetCategory_FullMethodName              = "/sappgrpc.ProductInfo/getCategory"
+	ProductInfo_UpdateCategory_FullMethodName           = "/sappgrpc.ProductInfo/updateCategory"
+	ProductInfo_DeleteCategory_FullMethodName           = "/sappgrpc.ProductInfo/deleteCategory"
+	ProductInfo_ListCategories_FullMethodName           = "/sappgrpc.ProductInfo/listCategories"
+	ProductInfo_CreateVariant_FullMethodName            = "/sappgrpc.ProductInfo/createVariant"
+	ProductInfo_GetVariant_FullMethodName               = "/sappgrpc.ProductInfo/getVariant"
+	ProductInfo_UpdateVariant_FullMethodName            = "/sappgrpc.ProductInfo/updateVariant"
+	ProductInfo_DeleteVariant_FullMethodName            = "/sappgrpc.ProductInfo/deleteVariant"
+	ProductInfo_ListVariants_FullMethodName             = "/sappgrpc.ProductInfo/listVariants"
+	ProductInfo_SchedulePriceChange_FullMethodName      = "/sappgrpc.ProductInfo/schedulePriceChange"
+	ProductInfo_CancelPriceChange_FullMethodName        = "/sappgrpc.ProductInfo/cancelPriceChange"
+	ProductInfo_ListPriceHistory_FullMethodName         = "/sappgrpc.ProductInfo/listPriceHistory"
+	ProductInfo_GetPriceAt_FullMethodName               = "/sappgrpc.ProductInfo/getPriceAt"
+	ProductInfo_RestoreProduct_FullMethodName           = "/sappgrpc.ProductInfo/restoreProduct"
+	ProductInfo_ListProductAuditEvents_FullMethodName   = "/sappgrpc.ProductInfo/listProductAuditEvents"
+	ProductInfo_FindDuplicates_FullMethodName           = "/sappgrpc.ProductInfo/findDuplicates"
 )
 
 // ProductInfoClient is the client API for ProductInfo service.
@@ -29,6 +59,35 @@ const (
 type ProductInfoClient interface {
 	AddProduct(ctx context.Context, in *Product, opts ...grpc.CallOption) (*ProductID, error)
 	GetProduct(ctx context.Context, in *ProductID, opts ...grpc.CallOption) (*Product, error)
+	BatchGetProducts(ctx context.Context, in *BatchGetProductsRequest, opts ...grpc.CallOption) (*BatchGetProductsResponse, error)
+	UpdateProduct(ctx context.Context, in *UpdateProductRequest, opts ...grpc.CallOption) (*Product, error)
+	DeleteProduct(ctx context.Context, in *ProductID, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ListProducts(ctx context.Context, in *ListProductsRequest, opts ...grpc.CallOption) (*ListProductsResponse, error)
+	ExportProducts(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Product], error)
+	ImportProducts(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Product, ImportProductsSummary], error)
+	WatchProducts(ctx context.Context, in *WatchProductsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ProductEvent], error)
+	SearchProducts(ctx context.Context, in *SearchProductsRequest, opts ...grpc.CallOption) (*SearchProductsResponse, error)
+	UploadProductAsset(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[UploadProductAssetRequest, ProductAsset], error)
+	DownloadProductAsset(ctx context.Context, in *DownloadProductAssetRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DownloadProductAssetResponse], error)
+	AddProductTranslation(ctx context.Context, in *AddProductTranslationRequest, opts ...grpc.CallOption) (*Product, error)
+	RemoveProductTranslation(ctx context.Context, in *RemoveProductTranslationRequest, opts ...grpc.CallOption) (*Product, error)
+	CreateCategory(ctx context.Context, in *Category, opts ...grpc.CallOption) (*Category, error)
+	GetCategory(ctx context.Context, in *CategoryPath, opts ...grpc.CallOption) (*Category, error)
+	UpdateCategory(ctx context.Context, in *Category, opts ...grpc.CallOption) (*Category, error)
+	DeleteCategory(ctx context.Context, in *CategoryPath, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ListCategories(ctx context.Context, in *ListCategoriesRequest, opts ...grpc.CallOption) (*ListCategoriesResponse, error)
+	CreateVariant(ctx context.Context, in *Variant, opts ...grpc.CallOption) (*Variant, error)
+	GetVariant(ctx context.Context, in *VariantID, opts ...grpc.CallOption) (*Variant, error)
+	UpdateVariant(ctx context.Context, in *Variant, opts ...grpc.CallOption) (*Variant, error)
+	DeleteVariant(ctx context.Context, in *VariantID, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ListVariants(ctx context.Context, in *ProductID, opts ...grpc.CallOption) (*ListVariantsResponse, error)
+	SchedulePriceChange(ctx context.Context, in *PriceChange, opts ...grpc.CallOption) (*PriceChange, error)
+	CancelPriceChange(ctx context.Context, in *PriceChangeID, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ListPriceHistory(ctx context.Context, in *ProductID, opts ...grpc.CallOption) (*ListPriceHistoryResponse, error)
+	GetPriceAt(ctx context.Context, in *GetPriceAtRequest, opts ...grpc.CallOption) (*PriceChange, error)
+	RestoreProduct(ctx context.Context, in *ProductID, opts ...grpc.CallOption) (*Product, error)
+	ListProductAuditEvents(ctx context.Context, in *ListProductAuditEventsRequest, opts ...grpc.CallOption) (*ListProductAuditEventsResponse, error)
+	FindDuplicates(ctx context.Context, in *FindDuplicatesRequest, opts ...grpc.CallOption) (*FindDuplicatesResponse, error)
 }
 
 type productInfoClient struct {
@@ -59,12 +118,364 @@ func (c *productInfoClient) GetProduct(ctx context.Context, in *ProductID, opts
 	return out, nil
 }
 
+func (c *productInfoClient) BatchGetProducts(ctx context.Context, in *BatchGetProductsRequest, opts ...grpc.CallOption) (*BatchGetProductsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchGetProductsResponse)
+	err := c.cc.Invoke(ctx, ProductInfo_BatchGetProducts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productInfoClient) UpdateProduct(ctx context.Context, in *UpdateProductRequest, opts ...grpc.CallOption) (*Product, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Product)
+	err := c.cc.Invoke(ctx, ProductInfo_UpdateProduct_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productInfoClient) DeleteProduct(ctx context.Context, in *ProductID, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ProductInfo_DeleteProduct_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productInfoClient) ListProducts(ctx context.Context, in *ListProductsRequest, opts ...grpc.CallOption) (*ListProductsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListProductsResponse)
+	err := c.cc.Invoke(ctx, ProductInfo_ListProducts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productInfoClient) ExportProducts(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Product], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ProductInfo_ServiceDesc.Streams[0], ProductInfo_ExportProducts_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[emptypb.Empty, Product]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ProductInfo_ExportProductsClient = grpc.ServerStreamingClient[Product]
+
+func (c *productInfoClient) ImportProducts(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Product, ImportProductsSummary], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ProductInfo_ServiceDesc.Streams[1], ProductInfo_ImportProducts_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Product, ImportProductsSummary]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ProductInfo_ImportProductsClient = grpc.ClientStreamingClient[Product, ImportProductsSummary]
+
+func (c *productInfoClient) WatchProducts(ctx context.Context, in *WatchProductsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ProductEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ProductInfo_ServiceDesc.Streams[2], ProductInfo_WatchProducts_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchProductsRequest, ProductEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ProductInfo_WatchProductsClient = grpc.ServerStreamingClient[ProductEvent]
+
+func (c *productInfoClient) SearchProducts(ctx context.Context, in *SearchProductsRequest, opts ...grpc.CallOption) (*SearchProductsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchProductsResponse)
+	err := c.cc.Invoke(ctx, ProductInfo_SearchProducts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productInfoClient) UploadProductAsset(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[UploadProductAssetRequest, ProductAsset], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ProductInfo_ServiceDesc.Streams[3], ProductInfo_UploadProductAsset_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[UploadProductAssetRequest, ProductAsset]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ProductInfo_UploadProductAssetClient = grpc.ClientStreamingClient[UploadProductAssetRequest, ProductAsset]
+
+func (c *productInfoClient) DownloadProductAsset(ctx context.Context, in *DownloadProductAssetRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DownloadProductAssetResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ProductInfo_ServiceDesc.Streams[4], ProductInfo_DownloadProductAsset_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[DownloadProductAssetRequest, DownloadProductAssetResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ProductInfo_DownloadProductAssetClient = grpc.ServerStreamingClient[DownloadProductAssetResponse]
+
+func (c *productInfoClient) AddProductTranslation(ctx context.Context, in *AddProductTranslationRequest, opts ...grpc.CallOption) (*Product, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Product)
+	err := c.cc.Invoke(ctx, ProductInfo_AddProductTranslation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productInfoClient) RemoveProductTranslation(ctx context.Context, in *RemoveProductTranslationRequest, opts ...grpc.CallOption) (*Product, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Product)
+	err := c.cc.Invoke(ctx, ProductInfo_RemoveProductTranslation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productInfoClient) CreateCategory(ctx context.Context, in *Category, opts ...grpc.CallOption) (*Category, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Category)
+	err := c.cc.Invoke(ctx, ProductInfo_CreateCategory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productInfoClient) GetCategory(ctx context.Context, in *CategoryPath, opts ...grpc.CallOption) (*Category, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Category)
+	err := c.cc.Invoke(ctx, ProductInfo_GetCategory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productInfoClient) UpdateCategory(ctx context.Context, in *Category, opts ...grpc.CallOption) (*Category, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Category)
+	err := c.cc.Invoke(ctx, ProductInfo_UpdateCategory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productInfoClient) DeleteCategory(ctx context.Context, in *CategoryPath, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ProductInfo_DeleteCategory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productInfoClient) ListCategories(ctx context.Context, in *ListCategoriesRequest, opts ...grpc.CallOption) (*ListCategoriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCategoriesResponse)
+	err := c.cc.Invoke(ctx, ProductInfo_ListCategories_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productInfoClient) CreateVariant(ctx context.Context, in *Variant, opts ...grpc.CallOption) (*Variant, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Variant)
+	err := c.cc.Invoke(ctx, ProductInfo_CreateVariant_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productInfoClient) GetVariant(ctx context.Context, in *VariantID, opts ...grpc.CallOption) (*Variant, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Variant)
+	err := c.cc.Invoke(ctx, ProductInfo_GetVariant_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productInfoClient) UpdateVariant(ctx context.Context, in *Variant, opts ...grpc.CallOption) (*Variant, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Variant)
+	err := c.cc.Invoke(ctx, ProductInfo_UpdateVariant_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productInfoClient) DeleteVariant(ctx context.Context, in *VariantID, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ProductInfo_DeleteVariant_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productInfoClient) ListVariants(ctx context.Context, in *ProductID, opts ...grpc.CallOption) (*ListVariantsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListVariantsResponse)
+	err := c.cc.Invoke(ctx, ProductInfo_ListVariants_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productInfoClient) SchedulePriceChange(ctx context.Context, in *PriceChange, opts ...grpc.CallOption) (*PriceChange, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PriceChange)
+	err := c.cc.Invoke(ctx, ProductInfo_SchedulePriceChange_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productInfoClient) CancelPriceChange(ctx context.Context, in *PriceChangeID, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ProductInfo_CancelPriceChange_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productInfoClient) ListPriceHistory(ctx context.Context, in *ProductID, opts ...grpc.CallOption) (*ListPriceHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListPriceHistoryResponse)
+	err := c.cc.Invoke(ctx, ProductInfo_ListPriceHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productInfoClient) GetPriceAt(ctx context.Context, in *GetPriceAtRequest, opts ...grpc.CallOption) (*PriceChange, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PriceChange)
+	err := c.cc.Invoke(ctx, ProductInfo_GetPriceAt_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productInfoClient) RestoreProduct(ctx context.Context, in *ProductID, opts ...grpc.CallOption) (*Product, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Product)
+	err := c.cc.Invoke(ctx, ProductInfo_RestoreProduct_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productInfoClient) ListProductAuditEvents(ctx context.Context, in *ListProductAuditEventsRequest, opts ...grpc.CallOption) (*ListProductAuditEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListProductAuditEventsResponse)
+	err := c.cc.Invoke(ctx, ProductInfo_ListProductAuditEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productInfoClient) FindDuplicates(ctx context.Context, in *FindDuplicatesRequest, opts ...grpc.CallOption) (*FindDuplicatesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FindDuplicatesResponse)
+	err := c.cc.Invoke(ctx, ProductInfo_FindDuplicates_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ProductInfoServer is the server API for ProductInfo service.
 // All implementations must embed UnimplementedProductInfoServer
 // for forward compatibility.
 type ProductInfoServer interface {
 	AddProduct(context.Context, *Product) (*ProductID, error)
 	GetProduct(context.Context, *ProductID) (*Product, error)
+	BatchGetProducts(context.Context, *BatchGetProductsRequest) (*BatchGetProductsResponse, error)
+	UpdateProduct(context.Context, *UpdateProductRequest) (*Product, error)
+	DeleteProduct(context.Context, *ProductID) (*emptypb.Empty, error)
+	ListProducts(context.Context, *ListProductsRequest) (*ListProductsResponse, error)
+	ExportProducts(*emptypb.Empty, grpc.ServerStreamingServer[Product]) error
+	ImportProducts(grpc.ClientStreamingServer[Product, ImportProductsSummary]) error
+	WatchProducts(*WatchProductsRequest, grpc.ServerStreamingServer[ProductEvent]) error
+	SearchProducts(context.Context, *SearchProductsRequest) (*SearchProductsResponse, error)
+	UploadProductAsset(grpc.ClientStreamingServer[UploadProductAssetRequest, ProductAsset]) error
+	DownloadProductAsset(*DownloadProductAssetRequest, grpc.ServerStreamingServer[DownloadProductAssetResponse]) error
+	AddProductTranslation(context.Context, *AddProductTranslationRequest) (*Product, error)
+	RemoveProductTranslation(context.Context, *RemoveProductTranslationRequest) (*Product, error)
+	CreateCategory(context.Context, *Category) (*Category, error)
+	GetCategory(context.Context, *CategoryPath) (*Category, error)
+	UpdateCategory(context.Context, *Category) (*Category, error)
+	DeleteCategory(context.Context, *CategoryPath) (*emptypb.Empty, error)
+	ListCategories(context.Context, *ListCategoriesRequest) (*ListCategoriesResponse, error)
+	CreateVariant(context.Context, *Variant) (*Variant, error)
+	GetVariant(context.Context, *VariantID) (*Variant, error)
+	UpdateVariant(context.Context, *Variant) (*Variant, error)
+	DeleteVariant(context.Context, *VariantID) (*emptypb.Empty, error)
+	ListVariants(context.Context, *ProductID) (*ListVariantsResponse, error)
+	SchedulePriceChange(context.Context, *PriceChange) (*PriceChange, error)
+	CancelPriceChange(context.Context, *PriceChangeID) (*emptypb.Empty, error)
+	ListPriceHistory(context.Context, *ProductID) (*ListPriceHistoryResponse, error)
+	GetPriceAt(context.Context, *GetPriceAtRequest) (*PriceChange, error)
+	RestoreProduct(context.Context, *ProductID) (*Product, error)
+	ListProductAuditEvents(context.Context, *ListProductAuditEventsRequest) (*ListProductAuditEventsResponse, error)
+	FindDuplicates(context.Context, *FindDuplicatesRequest) (*FindDuplicatesResponse, error)
 	mustEmbedUnimplementedProductInfoServer()
 }
 
@@ -81,6 +492,93 @@ func (UnimplementedProductInfoServer) AddProduct(context.Context, *Product) (*Pr
 func (UnimplementedProductInfoServer) GetProduct(context.Context, *ProductID) (*Product, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetProduct not implemented")
 }
+func (UnimplementedProductInfoServer) BatchGetProducts(context.Context, *BatchGetProductsRequest) (*BatchGetProductsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchGetProducts not implemented")
+}
+func (UnimplementedProductInfoServer) UpdateProduct(context.Context, *UpdateProductRequest) (*Product, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateProduct not implemented")
+}
+func (UnimplementedProductInfoServer) DeleteProduct(context.Context, *ProductID) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteProduct not implemented")
+}
+func (UnimplementedProductInfoServer) ListProducts(context.Context, *ListProductsRequest) (*ListProductsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListProducts not implemented")
+}
+func (UnimplementedProductInfoServer) ExportProducts(*emptypb.Empty, grpc.ServerStreamingServer[Product]) error {
+	return status.Errorf(codes.Unimplemented, "method ExportProducts not implemented")
+}
+func (UnimplementedProductInfoServer) ImportProducts(grpc.ClientStreamingServer[Product, ImportProductsSummary]) error {
+	return status.Errorf(codes.Unimplemented, "method ImportProducts not implemented")
+}
+func (UnimplementedProductInfoServer) WatchProducts(*WatchProductsRequest, grpc.ServerStreamingServer[ProductEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchProducts not implemented")
+}
+func (UnimplementedProductInfoServer) SearchProducts(context.Context, *SearchProductsRequest) (*SearchProductsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchProducts not implemented")
+}
+func (UnimplementedProductInfoServer) UploadProductAsset(grpc.ClientStreamingServer[UploadProductAssetRequest, ProductAsset]) error {
+	return status.Errorf(codes.Unimplemented, "method UploadProductAsset not implemented")
+}
+func (UnimplementedProductInfoServer) DownloadProductAsset(*DownloadProductAssetRequest, grpc.ServerStreamingServer[DownloadProductAssetResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method DownloadProductAsset not implemented")
+}
+func (UnimplementedProductInfoServer) AddProductTranslation(context.Context, *AddProductTranslationRequest) (*Product, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddProductTranslation not implemented")
+}
+func (UnimplementedProductInfoServer) RemoveProductTranslation(context.Context, *RemoveProductTranslationRequest) (*Product, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveProductTranslation not implemented")
+}
+func (UnimplementedProductInfoServer) CreateCategory(context.Context, *Category) (*Category, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateCategory not implemented")
+}
+func (UnimplementedProductInfoServer) GetCategory(context.Context, *CategoryPath) (*Category, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCategory not implemented")
+}
+func (UnimplementedProductInfoServer) UpdateCategory(context.Context, *Category) (*Category, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateCategory not implemented")
+}
+func (UnimplementedProductInfoServer) DeleteCategory(context.Context, *CategoryPath) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCategory not implemented")
+}
+func (UnimplementedProductInfoServer) ListCategories(context.Context, *ListCategoriesRequest) (*ListCategoriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCategories not implemented")
+}
+func (UnimplementedProductInfoServer) CreateVariant(context.Context, *Variant) (*Variant, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateVariant not implemented")
+}
+func (UnimplementedProductInfoServer) GetVariant(context.Context, *VariantID) (*Variant, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetVariant not implemented")
+}
+func (UnimplementedProductInfoServer) UpdateVariant(context.Context, *Variant) (*Variant, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateVariant not implemented")
+}
+func (UnimplementedProductInfoServer) DeleteVariant(context.Context, *VariantID) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteVariant not implemented")
+}
+func (UnimplementedProductInfoServer) ListVariants(context.Context, *ProductID) (*ListVariantsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListVariants not implemented")
+}
+func (UnimplementedProductInfoServer) SchedulePriceChange(context.Context, *PriceChange) (*PriceChange, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SchedulePriceChange not implemented")
+}
+func (UnimplementedProductInfoServer) CancelPriceChange(context.Context, *PriceChangeID) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelPriceChange not implemented")
+}
+func (UnimplementedProductInfoServer) ListPriceHistory(context.Context, *ProductID) (*ListPriceHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPriceHistory not implemented")
+}
+func (UnimplementedProductInfoServer) GetPriceAt(context.Context, *GetPriceAtRequest) (*PriceChange, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPriceAt not implemented")
+}
+func (UnimplementedProductInfoServer) RestoreProduct(context.Context, *ProductID) (*Product, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreProduct not implemented")
+}
+func (UnimplementedProductInfoServer) ListProductAuditEvents(context.Context, *ListProductAuditEventsRequest) (*ListProductAuditEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListProductAuditEvents not implemented")
+}
+func (UnimplementedProductInfoServer) FindDuplicates(context.Context, *FindDuplicatesRequest) (*FindDuplicatesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindDuplicates not implemented")
+}
 func (UnimplementedProductInfoServer) mustEmbedUnimplementedProductInfoServer() {}
 func (UnimplementedProductInfoServer) testEmbeddedByValue()                     {}
 
@@ -138,14 +636,493 @@ func _ProductInfo_GetProduct_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
-// ProductInfo_ServiceDesc is the grpc.ServiceDesc for ProductInfo service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var ProductInfo_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "sappgrpc.ProductInfo",
-	HandlerType: (*ProductInfoServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
+func _ProductInfo_BatchGetProducts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchGetProductsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).BatchGetProducts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductInfo_BatchGetProducts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).BatchGetProducts(ctx, req.(*BatchGetProductsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductInfo_UpdateProduct_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateProductRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).UpdateProduct(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductInfo_UpdateProduct_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).UpdateProduct(ctx, req.(*UpdateProductRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductInfo_DeleteProduct_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProductID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).DeleteProduct(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductInfo_DeleteProduct_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).DeleteProduct(ctx, req.(*ProductID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductInfo_ListProducts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListProductsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).ListProducts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductInfo_ListProducts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).ListProducts(ctx, req.(*ListProductsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductInfo_ExportProducts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(emptypb.Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ProductInfoServer).ExportProducts(m, &grpc.GenericServerStream[emptypb.Empty, Product]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ProductInfo_ExportProductsServer = grpc.ServerStreamingServer[Product]
+
+func _ProductInfo_ImportProducts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ProductInfoServer).ImportProducts(&grpc.GenericServerStream[Product, ImportProductsSummary]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ProductInfo_ImportProductsServer = grpc.ClientStreamingServer[Product, ImportProductsSummary]
+
+func _ProductInfo_WatchProducts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchProductsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ProductInfoServer).WatchProducts(m, &grpc.GenericServerStream[WatchProductsRequest, ProductEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ProductInfo_WatchProductsServer = grpc.ServerStreamingServer[ProductEvent]
+
+func _ProductInfo_SearchProducts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchProductsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).SearchProducts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductInfo_SearchProducts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).SearchProducts(ctx, req.(*SearchProductsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductInfo_UploadProductAsset_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ProductInfoServer).UploadProductAsset(&grpc.GenericServerStream[UploadProductAssetRequest, ProductAsset]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ProductInfo_UploadProductAssetServer = grpc.ClientStreamingServer[UploadProductAssetRequest, ProductAsset]
+
+func _ProductInfo_DownloadProductAsset_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DownloadProductAssetRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ProductInfoServer).DownloadProductAsset(m, &grpc.GenericServerStream[DownloadProductAssetRequest, DownloadProductAssetResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ProductInfo_DownloadProductAssetServer = grpc.ServerStreamingServer[DownloadProductAssetResponse]
+
+func _ProductInfo_AddProductTranslation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddProductTranslationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).AddProductTranslation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductInfo_AddProductTranslation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).AddProductTranslation(ctx, req.(*AddProductTranslationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductInfo_RemoveProductTranslation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveProductTranslationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).RemoveProductTranslation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductInfo_RemoveProductTranslation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).RemoveProductTranslation(ctx, req.(*RemoveProductTranslationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductInfo_CreateCategory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Category)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).CreateCategory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductInfo_CreateCategory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).CreateCategory(ctx, req.(*Category))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductInfo_GetCategory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CategoryPath)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).GetCategory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductInfo_GetCategory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).GetCategory(ctx, req.(*CategoryPath))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductInfo_UpdateCategory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Category)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).UpdateCategory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductInfo_UpdateCategory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).UpdateCategory(ctx, req.(*Category))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductInfo_DeleteCategory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CategoryPath)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).DeleteCategory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductInfo_DeleteCategory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).DeleteCategory(ctx, req.(*CategoryPath))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductInfo_ListCategories_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCategoriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).ListCategories(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductInfo_ListCategories_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).ListCategories(ctx, req.(*ListCategoriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductInfo_CreateVariant_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Variant)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).CreateVariant(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductInfo_CreateVariant_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).CreateVariant(ctx, req.(*Variant))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductInfo_GetVariant_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VariantID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).GetVariant(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductInfo_GetVariant_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).GetVariant(ctx, req.(*VariantID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductInfo_UpdateVariant_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Variant)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).UpdateVariant(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductInfo_UpdateVariant_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).UpdateVariant(ctx, req.(*Variant))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductInfo_DeleteVariant_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VariantID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).DeleteVariant(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductInfo_DeleteVariant_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).DeleteVariant(ctx, req.(*VariantID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductInfo_ListVariants_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProductID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).ListVariants(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductInfo_ListVariants_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).ListVariants(ctx, req.(*ProductID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductInfo_SchedulePriceChange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PriceChange)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).SchedulePriceChange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductInfo_SchedulePriceChange_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).SchedulePriceChange(ctx, req.(*PriceChange))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductInfo_CancelPriceChange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PriceChangeID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).CancelPriceChange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductInfo_CancelPriceChange_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).CancelPriceChange(ctx, req.(*PriceChangeID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductInfo_ListPriceHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProductID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).ListPriceHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductInfo_ListPriceHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).ListPriceHistory(ctx, req.(*ProductID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductInfo_GetPriceAt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPriceAtRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).GetPriceAt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductInfo_GetPriceAt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).GetPriceAt(ctx, req.(*GetPriceAtRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductInfo_RestoreProduct_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProductID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).RestoreProduct(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductInfo_RestoreProduct_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).RestoreProduct(ctx, req.(*ProductID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductInfo_ListProductAuditEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListProductAuditEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).ListProductAuditEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductInfo_ListProductAuditEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).ListProductAuditEvents(ctx, req.(*ListProductAuditEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductInfo_FindDuplicates_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindDuplicatesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductInfoServer).FindDuplicates(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductInfo_FindDuplicates_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductInfoServer).FindDuplicates(ctx, req.(*FindDuplicatesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ProductInfo_ServiceDesc is the grpc.ServiceDesc for ProductInfo service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ProductInfo_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "sappgrpc.ProductInfo",
+	HandlerType: (*ProductInfoServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
 			MethodName: "addProduct",
 			Handler:    _ProductInfo_AddProduct_Handler,
 		},
@@ -153,7 +1130,129 @@ var ProductInfo_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "getProduct",
 			Handler:    _ProductInfo_GetProduct_Handler,
 		},
+		{
+			MethodName: "batchGetProducts",
+			Handler:    _ProductInfo_BatchGetProducts_Handler,
+		},
+		{
+			MethodName: "updateProduct",
+			Handler:    _ProductInfo_UpdateProduct_Handler,
+		},
+		{
+			MethodName: "deleteProduct",
+			Handler:    _ProductInfo_DeleteProduct_Handler,
+		},
+		{
+			MethodName: "listProducts",
+			Handler:    _ProductInfo_ListProducts_Handler,
+		},
+		{
+			MethodName: "searchProducts",
+			Handler:    _ProductInfo_SearchProducts_Handler,
+		},
+		{
+			MethodName: "addProductTranslation",
+			Handler:    _ProductInfo_AddProductTranslation_Handler,
+		},
+		{
+			MethodName: "removeProductTranslation",
+			Handler:    _ProductInfo_RemoveProductTranslation_Handler,
+		},
+		{
+			MethodName: "createCategory",
+			Handler:    _ProductInfo_CreateCategory_Handler,
+		},
+		{
+			MethodName: "getCategory",
+			Handler:    _ProductInfo_GetCategory_Handler,
+		},
+		{
+			MethodName: "updateCategory",
+			Handler:    _ProductInfo_UpdateCategory_Handler,
+		},
+		{
+			MethodName: "deleteCategory",
+			Handler:    _ProductInfo_DeleteCategory_Handler,
+		},
+		{
+			MethodName: "listCategories",
+			Handler:    _ProductInfo_ListCategories_Handler,
+		},
+		{
+			MethodName: "createVariant",
+			Handler:    _ProductInfo_CreateVariant_Handler,
+		},
+		{
+			MethodName: "getVariant",
+			Handler:    _ProductInfo_GetVariant_Handler,
+		},
+		{
+			MethodName: "updateVariant",
+			Handler:    _ProductInfo_UpdateVariant_Handler,
+		},
+		{
+			MethodName: "deleteVariant",
+			Handler:    _ProductInfo_DeleteVariant_Handler,
+		},
+		{
+			MethodName: "listVariants",
+			Handler:    _ProductInfo_ListVariants_Handler,
+		},
+		{
+			MethodName: "schedulePriceChange",
+			Handler:    _ProductInfo_SchedulePriceChange_Handler,
+		},
+		{
+			MethodName: "cancelPriceChange",
+			Handler:    _ProductInfo_CancelPriceChange_Handler,
+		},
+		{
+			MethodName: "listPriceHistory",
+			Handler:    _ProductInfo_ListPriceHistory_Handler,
+		},
+		{
+			MethodName: "getPriceAt",
+			Handler:    _ProductInfo_GetPriceAt_Handler,
+		},
+		{
+			MethodName: "restoreProduct",
+			Handler:    _ProductInfo_RestoreProduct_Handler,
+		},
+		{
+			MethodName: "listProductAuditEvents",
+			Handler:    _ProductInfo_ListProductAuditEvents_Handler,
+		},
+		{
+			MethodName: "findDuplicates",
+			Handler:    _ProductInfo_FindDuplicates_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "exportProducts",
+			Handler:       _ProductInfo_ExportProducts_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "importProducts",
+			Handler:       _ProductInfo_ImportProducts_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "watchProducts",
+			Handler:       _ProductInfo_WatchProducts_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "uploadProductAsset",
+			Handler:       _ProductInfo_UploadProductAsset_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "downloadProductAsset",
+			Handler:       _ProductInfo_DownloadProductAsset_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "sappgrpc.proto",
 }
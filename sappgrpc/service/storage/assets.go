@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"strings"
+
+	pb "service/sappgrpc"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	maxAssetSize   = 64 << 20
+	assetChunkSize = 64 << 10
+)
+
+var errChecksumMismatch = errors.New("asset checksum mismatch")
+
+// assetDir names the directory of the assets of a product. Product ids
+// are only unique per tenant, so it starts with the tenant of ctx.
+func assetDir(ctx context.Context, productID string) string {
+	return TenantFromContext(ctx) + "/" + productID
+}
+
+func assetKey(ctx context.Context, productID, name string) string {
+	return assetDir(ctx, productID) + "/" + name
+}
+
+// deleteAssets removes all assets of a product.
+func (c *ProductService) deleteAssets(ctx context.Context, productID string) error {
+	keys, err := c.blobs.List(ctx, assetDir(ctx, productID))
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := c.blobs.Delete(ctx, key); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateAssetName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\\x00") {
+		return status.Errorf(codes.InvalidArgument, "Invalid asset name: %q", name)
+	}
+	return nil
+}
+
+// assetReader feeds the chunks of an upload stream to a BlobStore while
+// hashing them. It fails the read at the end of the stream when the
+// content does not match the announced checksum, so the store discards it.
+type assetReader struct {
+	stream pb.ProductInfo_UploadProductAssetServer
+	want   string
+	hash   hash.Hash
+	size   int64
+	buf    []byte
+	err    error
+}
+
+func (r *assetReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		req, err := r.stream.Recv()
+		if err == io.EOF {
+			if hex.EncodeToString(r.hash.Sum(nil)) != strings.ToLower(r.want) {
+				r.err = errChecksumMismatch
+				return 0, r.err
+			}
+			return 0, io.EOF
+		}
+		if err != nil {
+			r.err = err
+			return 0, err
+		}
+		chunk := req.GetChunk()
+		if req.GetInfo() != nil || chunk == nil {
+			r.err = status.Errorf(codes.InvalidArgument, "Expected an asset chunk")
+			return 0, r.err
+		}
+		r.size += int64(len(chunk))
+		if r.size > maxAssetSize {
+			r.err = status.Errorf(codes.ResourceExhausted, "Asset is larger than %d bytes", maxAssetSize)
+			return 0, r.err
+		}
+		r.hash.Write(chunk)
+		r.buf = chunk
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (c *ProductService) UploadProductAsset(stream pb.ProductInfo_UploadProductAssetServer) error {
+	if c.blobs == nil {
+		return status.Errorf(codes.Unimplemented, "Product assets are not configured")
+	}
+	ctx := stream.Context()
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	info := req.GetInfo()
+	if info == nil {
+		return status.Errorf(codes.InvalidArgument, "The first message must carry the asset info")
+	}
+	if err := validateAssetName(info.Name); err != nil {
+		return err
+	}
+	if b, err := hex.DecodeString(info.Sha256); err != nil || len(b) != sha256.Size {
+		return status.Errorf(codes.InvalidArgument, "Asset sha256 must be a hex encoded SHA-256 checksum")
+	}
+	if _, err := c.Store.Get(ctx, info.ProductId); err != nil {
+		return toStatus(err, "get", info.ProductId)
+	}
+
+	r := &assetReader{stream: stream, want: info.Sha256, hash: sha256.New()}
+	blob := BlobInfo{ContentType: info.ContentType, SHA256: strings.ToLower(info.Sha256), CreatedAt: now()}
+	if err := c.blobs.Put(ctx, assetKey(ctx, info.ProductId, info.Name), r, blob); err != nil {
+		switch {
+		case errors.Is(r.err, errChecksumMismatch):
+			return status.Errorf(codes.DataLoss, "Asset checksum does not match, expected %s", info.Sha256)
+		case r.err != nil:
+			return r.err
+		}
+		return toStatus(err, "upload asset of", info.ProductId)
+	}
+	return stream.SendAndClose(&pb.ProductAsset{
+		ProductId:   info.ProductId,
+		Name:        info.Name,
+		ContentType: info.ContentType,
+		Size:        r.size,
+		Sha256:      blob.SHA256,
+		CreateTime:  toTimestamp(blob.CreatedAt),
+	})
+}
+
+func (c *ProductService) DownloadProductAsset(req *pb.DownloadProductAssetRequest, stream pb.ProductInfo_DownloadProductAssetServer) error {
+	if c.blobs == nil {
+		return status.Errorf(codes.Unimplemented, "Product assets are not configured")
+	}
+	if err := validateAssetName(req.Name); err != nil {
+		return err
+	}
+	ctx := stream.Context()
+	if _, err := c.Store.Get(ctx, req.ProductId); err != nil {
+		return toStatus(err, "get", req.ProductId)
+	}
+	rc, info, err := c.blobs.Open(ctx, assetKey(ctx, req.ProductId, req.Name))
+	if errors.Is(err, ErrNotFound) {
+		return status.Errorf(codes.NotFound, "No asset %q was found for product %s", req.Name, req.ProductId)
+	}
+	if err != nil {
+		return toStatus(err, "download asset of", req.ProductId)
+	}
+	defer rc.Close()
+	err = stream.Send(&pb.DownloadProductAssetResponse{Data: &pb.DownloadProductAssetResponse_Info{Info: &pb.ProductAsset{
+		ProductId:   req.ProductId,
+		Name:        req.Name,
+		ContentType: info.ContentType,
+		Size:        info.Size,
+		Sha256:      info.SHA256,
+		CreateTime:  toTimestamp(info.CreatedAt),
+	}}})
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, assetChunkSize)
+	for {
+		n, err := rc.Read(buf)
+		if n > 0 {
+			chunk := &pb.DownloadProductAssetResponse_Chunk{Chunk: buf[:n]}
+			if err := stream.Send(&pb.DownloadProductAssetResponse{Data: chunk}); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return toStatus(err, "download asset of", req.ProductId)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"log/slog"
+
+	pb "service/sappgrpc"
+
+	"github.com/gofrs/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// AuditCollectionName is the MongoDB collection of product audit events.
+const AuditCollectionName = "audit_events"
+
+const (
+	actorHeader  = "actor"
+	unknownActor = "unknown"
+	// SystemActor is recorded for changes made by the server itself.
+	SystemActor = "system"
+)
+
+// auditIgnoredFields change with every write or are not part of the
+// stored product.
+var auditIgnoredFields = map[protoreflect.Name]bool{
+	"update_time": true,
+	"etag":        true,
+	"language":    true,
+	"variants":    true,
+}
+
+// AuditStore keeps the audit trail of products. Like ProductStore every
+// call acts on the tenant set on ctx.
+type AuditStore interface {
+	// AppendAudit stores e. Stored events are never changed.
+	AppendAudit(ctx context.Context, e *pb.AuditEvent) error
+	// ListAudit returns up to limit events of a product with ids after
+	// after, oldest first.
+	ListAudit(ctx context.Context, productID, after string, limit int) ([]*pb.AuditEvent, error)
+}
+
+// actor returns the actor request header, or "unknown" if it is missing.
+func actor(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if v := md.Get(actorHeader); len(v) > 0 && v[0] != "" {
+		return v[0]
+	}
+	return unknownActor
+}
+
+// changedFields lists the top-level fields that differ between before and
+// after. A nil before stands for a product that did not exist.
+func changedFields(before, after *pb.Product) []string {
+	if before == nil {
+		before = &pb.Product{}
+	}
+	b, a := before.ProtoReflect(), after.ProtoReflect()
+	var changed []string
+	fields := a.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if auditIgnoredFields[fd.Name()] || (!b.Has(fd) && !a.Has(fd)) {
+			continue
+		}
+		if b.Has(fd) != a.Has(fd) || !b.Get(fd).Equal(a.Get(fd)) {
+			changed = append(changed, string(fd.Name()))
+		}
+	}
+	return changed
+}
+
+// record appends an audit event for the change of a product from before
+// to after. The change is already stored, so a failure is only logged.
+func (c *ProductService) record(ctx context.Context, who string, action pb.AuditEvent_Action, before, after *pb.Product) {
+	if c.audit == nil {
+		return
+	}
+	id, err := uuid.NewV7()
+	if err != nil {
+		slog.Error("Failed to record audit event", "action", action.String(), "product", after.Id, "err", err)
+		return
+	}
+	snapshot := proto.Clone(after).(*pb.Product)
+	snapshot.Language = ""
+	snapshot.Variants = nil
+	e := &pb.AuditEvent{
+		Id:            id.String(),
+		ProductId:     after.Id,
+		Action:        action,
+		Actor:         who,
+		EventTime:     toTimestamp(now()),
+		ChangedFields: changedFields(before, after),
+		Product:       snapshot,
+	}
+	if err := c.audit.AppendAudit(context.WithoutCancel(ctx), e); err != nil {
+		slog.Error("Failed to record audit event", "action", action.String(), "product", after.Id, "err", err)
+	}
+}
+
+// ListProductAuditEvents pages through the audit trail of a product,
+// oldest event first. The trail of a purged product stays readable.
+func (c *ProductService) ListProductAuditEvents(ctx context.Context, req *pb.ListProductAuditEventsRequest) (*pb.ListProductAuditEventsResponse, error) {
+	if c.audit == nil {
+		return nil, status.Errorf(codes.Unimplemented, "Audit trail is not configured")
+	}
+	if req.ProductId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "Product ID is required")
+	}
+	token, err := decodePageToken(req.PageToken)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid page token: %v", err)
+	}
+	size := pageSize(req.PageSize)
+	events, err := c.audit.ListAudit(ctx, req.ProductId, token.After, size+1)
+	if err != nil {
+		return nil, toStatus(err, "list audit events of", req.ProductId)
+	}
+	res := &pb.ListProductAuditEventsResponse{Events: events}
+	if len(events) > size {
+		res.Events = events[:size]
+		res.NextPageToken = encodePageToken(pageToken{After: events[size-1].Id})
+	}
+	return res, nil
+}
@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// BlobInfo describes stored content.
+type BlobInfo struct {
+	ContentType string    `json:"content_type" bson:"content_type"`
+	Size        int64     `json:"size" bson:"size"`
+	SHA256      string    `json:"sha256" bson:"sha256"`
+	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
+}
+
+// BlobStore keeps product assets. Put replaces content stored under the
+// same key and must not keep anything if r returns an error.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, info BlobInfo) error
+	// Open returns ErrNotFound for unknown keys.
+	Open(ctx context.Context, key string) (io.ReadCloser, BlobInfo, error)
+	Delete(ctx context.Context, key string) error
+	// List returns the keys below dir, that is starting with dir + "/".
+	List(ctx context.Context, dir string) ([]string, error)
+}
@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FSBlobStore keeps blobs as files below Dir, with the BlobInfo in a JSON
+// file next to each of them.
+type FSBlobStore struct {
+	Dir string
+}
+
+func NewFSBlobStore(dir string) (*FSBlobStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FSBlobStore{Dir: dir}, nil
+}
+
+var errInvalidKey = errors.New("invalid blob key")
+
+// path maps a key to a file name. Every key segment is escaped, so keys
+// can not point outside of Dir. Empty segments are rejected, as Join
+// would drop them and map the key onto another one, or onto Dir itself.
+func (s *FSBlobStore) path(key string) (string, error) {
+	parts := strings.Split(key, "/")
+	for i, p := range parts {
+		if p == "" {
+			return "", fmt.Errorf("%w: %q", errInvalidKey, key)
+		}
+		parts[i] = url.PathEscape(p)
+		if parts[i] == "." || parts[i] == ".." {
+			parts[i] = strings.ReplaceAll(parts[i], ".", "%2E")
+		}
+	}
+	return filepath.Join(append([]string{s.Dir}, parts...)...), nil
+}
+
+func (s *FSBlobStore) Put(ctx context.Context, key string, r io.Reader, info BlobInfo) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	n, err := io.Copy(tmp, r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	info.Size = n
+	meta, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".json", meta, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *FSBlobStore) Open(ctx context.Context, key string) (io.ReadCloser, BlobInfo, error) {
+	var info BlobInfo
+	path, err := s.path(key)
+	if err != nil {
+		return nil, info, err
+	}
+	meta, err := os.ReadFile(path + ".json")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, info, ErrNotFound
+	}
+	if err != nil {
+		return nil, info, err
+	}
+	if err := json.Unmarshal(meta, &info); err != nil {
+		return nil, info, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, info, ErrNotFound
+	}
+	if err != nil {
+		return nil, info, err
+	}
+	return f, info, nil
+}
+
+// List skips the metadata files and unfinished uploads.
+func (s *FSBlobStore) List(ctx context.Context, dir string) ([]string, error) {
+	root, err := s.path(dir)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == root {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(d.Name(), ".json") || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		for i, p := range parts {
+			if parts[i], err = url.PathUnescape(p); err != nil {
+				return err
+			}
+		}
+		keys = append(keys, dir+"/"+strings.Join(parts, "/"))
+		return nil
+	})
+	return keys, err
+}
+
+func (s *FSBlobStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path + ".json"); err != nil {
+		return err
+	}
+	// Drop the directory of the product once its last asset is gone.
+	if dir := filepath.Dir(path); dir != filepath.Clean(s.Dir) {
+		os.Remove(dir)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFSBlobStoreKeepsKeysBelowDir(t *testing.T) {
+	parent := t.TempDir()
+	s, err := NewFSBlobStore(filepath.Join(parent, "blobs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	keys := []string{"p/..", "p/.", "p/x/../../y", "p/a%2Fb", "p/%2F", "p/a b", "p/.hidden"}
+	for _, key := range keys {
+		if err := s.Put(ctx, key, strings.NewReader(key), BlobInfo{ContentType: "text/plain"}); err != nil {
+			t.Fatalf("put %q: %v", key, err)
+		}
+	}
+
+	for _, key := range keys {
+		r, info, err := s.Open(ctx, key)
+		if err != nil {
+			t.Fatalf("open %q: %v", key, err)
+		}
+		b, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != key || info.Size != int64(len(key)) {
+			t.Errorf("open %q: got %q (%d bytes)", key, b, info.Size)
+		}
+	}
+	listed, err := s.List(ctx, "p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != len(keys) {
+		t.Fatalf("got keys %q, want %q", listed, keys)
+	}
+	for _, key := range keys {
+		found := false
+		for _, k := range listed {
+			found = found || k == key
+		}
+		if !found {
+			t.Errorf("key %q is missing from %q", key, listed)
+		}
+	}
+
+	err = filepath.WalkDir(parent, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if rel, _ := filepath.Rel(s.Dir, path); path != parent && strings.HasPrefix(rel, "..") {
+			t.Errorf("%s was written outside of %s", path, s.Dir)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range keys {
+		if err := s.Delete(ctx, key); err != nil {
+			t.Fatalf("delete %q: %v", key, err)
+		}
+		if _, _, err := s.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("open %q after delete: got %v, want ErrNotFound", key, err)
+		}
+	}
+}
+
+func TestFSBlobStoreRejectsEmptySegments(t *testing.T) {
+	parent := t.TempDir()
+	s, err := NewFSBlobStore(filepath.Join(parent, "blobs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, key := range []string{"", "/", "//", "p/", "/x", "p//x"} {
+		if err := s.Put(ctx, key, strings.NewReader("x"), BlobInfo{}); !errors.Is(err, errInvalidKey) {
+			t.Errorf("put %q: got %v, want errInvalidKey", key, err)
+		}
+		if _, _, err := s.Open(ctx, key); !errors.Is(err, errInvalidKey) {
+			t.Errorf("open %q: got %v, want errInvalidKey", key, err)
+		}
+		if err := s.Delete(ctx, key); !errors.Is(err, errInvalidKey) {
+			t.Errorf("delete %q: got %v, want errInvalidKey", key, err)
+		}
+	}
+	if _, err := s.List(ctx, ""); !errors.Is(err, errInvalidKey) {
+		t.Errorf("list: got %v, want errInvalidKey", err)
+	}
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries next to the blob directory, want none", len(entries)-1)
+	}
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// AssetBucketName is the GridFS bucket of product assets.
+const AssetBucketName = "assets"
+
+// GridFSBlobStore keeps blobs in a MongoDB GridFS bucket, using the key as
+// the file name and the BlobInfo as file metadata.
+type GridFSBlobStore struct {
+	Bucket *mongo.GridFSBucket
+}
+
+func NewGridFSBlobStore(db *mongo.Database) *GridFSBlobStore {
+	return &GridFSBlobStore{Bucket: db.GridFSBucket(options.GridFSBucket().SetName(AssetBucketName))}
+}
+
+// Put uploads a new revision and then removes the older ones, so readers
+// see either the old or the new content.
+func (s *GridFSBlobStore) Put(ctx context.Context, key string, r io.Reader, info BlobInfo) error {
+	id, err := s.Bucket.UploadFromStream(ctx, key, r, options.GridFSUpload().SetMetadata(info))
+	if err != nil {
+		return err
+	}
+	return s.deleteRevisions(ctx, key, id)
+}
+
+func (s *GridFSBlobStore) Open(ctx context.Context, key string) (io.ReadCloser, BlobInfo, error) {
+	var info BlobInfo
+	ds, err := s.Bucket.OpenDownloadStreamByName(ctx, key)
+	if errors.Is(err, mongo.ErrFileNotFound) {
+		return nil, info, ErrNotFound
+	}
+	if err != nil {
+		return nil, info, err
+	}
+	f := ds.GetFile()
+	if err := bson.Unmarshal(f.Metadata, &info); err != nil {
+		ds.Close()
+		return nil, info, err
+	}
+	info.Size = f.Length
+	return ds, info, nil
+}
+
+func (s *GridFSBlobStore) Delete(ctx context.Context, key string) error {
+	files, err := s.find(ctx, key)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return ErrNotFound
+	}
+	return s.deleteRevisions(ctx, key, bson.NilObjectID)
+}
+
+func (s *GridFSBlobStore) List(ctx context.Context, dir string) ([]string, error) {
+	filter := bson.D{{Key: "filename", Value: bson.Regex{Pattern: "^" + regexp.QuoteMeta(dir+"/")}}}
+	cur, err := s.Bucket.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var files []struct {
+		Name string `bson:"filename"`
+	}
+	if err := cur.All(ctx, &files); err != nil {
+		return nil, err
+	}
+	// Files with several revisions are listed once.
+	var keys []string
+	seen := make(map[string]bool)
+	for _, f := range files {
+		if !seen[f.Name] {
+			seen[f.Name] = true
+			keys = append(keys, f.Name)
+		}
+	}
+	return keys, nil
+}
+
+// deleteRevisions removes every file named key except keep.
+func (s *GridFSBlobStore) deleteRevisions(ctx context.Context, key string, keep bson.ObjectID) error {
+	files, err := s.find(ctx, key)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.ID == keep {
+			continue
+		}
+		if err := s.Bucket.Delete(ctx, f.ID); err != nil && !errors.Is(err, mongo.ErrFileNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+type gridFSFile struct {
+	ID bson.ObjectID `bson:"_id"`
+}
+
+func (s *GridFSBlobStore) find(ctx context.Context, key string) ([]gridFSFile, error) {
+	cur, err := s.Bucket.Find(ctx, bson.D{{Key: "filename", Value: key}})
+	if err != nil {
+		return nil, err
+	}
+	var files []gridFSFile
+	err = cur.All(ctx, &files)
+	return files, err
+}
@@ -0,0 +1,272 @@
+package storage
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pb "service/sappgrpc"
+
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/protobuf/proto"
+)
+
+// CacheStats are the counters of a CachedStore.
+type CacheStats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Size      int    `json:"size"`
+}
+
+// loadTimeout bounds a backend read shared by concurrent cache misses.
+const loadTimeout = 10 * time.Second
+
+type cacheEntry struct {
+	key     string
+	product *pb.Product
+	expires time.Time
+}
+
+// pendingLoad is a backend read of one key in progress.
+type pendingLoad struct {
+	gen  uint64
+	refs int
+}
+
+// CachedStore is a read-through LRU cache in front of a ProductStore.
+// Concurrent misses for the same product are coalesced into one backend
+// read. Writes made through the cache invalidate the cached product;
+// writes by other processes are picked up once the entry expires.
+// Products are cached per tenant, so a read from another tenant still
+// reaches the backend and is rejected there.
+type CachedStore struct {
+	ProductStore
+
+	size int
+	ttl  time.Duration
+
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+	// loads holds the keys being read from the backend. Invalidating a
+	// key bumps its generation so a read that started before a write
+	// does not put the stale product back.
+	loads map[string]*pendingLoad
+	group singleflight.Group
+
+	hits, misses, evictions atomic.Uint64
+}
+
+func NewCachedStore(store ProductStore, size int, ttl time.Duration) *CachedStore {
+	return &CachedStore{
+		ProductStore: store,
+		size:         size,
+		ttl:          ttl,
+		ll:           list.New(),
+		items:        make(map[string]*list.Element),
+		loads:        make(map[string]*pendingLoad),
+	}
+}
+
+func (c *CachedStore) Get(ctx context.Context, id string) (*pb.Product, error) {
+	key := cacheKey(ctx, id)
+	if p, ok := c.lookup(key); ok {
+		c.hits.Add(1)
+		return p, nil
+	}
+	c.misses.Add(1)
+	// The read is shared, so it must not fail because the caller that
+	// started it went away. Every caller still gives up on its own ctx.
+	ch := c.group.DoChan(key, func() (interface{}, error) {
+		gen := c.startLoad(key)
+		lctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), loadTimeout)
+		defer cancel()
+		p, err := c.ProductStore.Get(lctx, id)
+		if err != nil {
+			c.finishLoad(key, gen, nil)
+			return nil, err
+		}
+		c.finishLoad(key, gen, p)
+		return p, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-ch:
+		if r.Err != nil {
+			return nil, r.Err
+		}
+		return proto.Clone(r.Val.(*pb.Product)).(*pb.Product), nil
+	}
+}
+
+// GetMany serves cached products and reads the others from the backend
+// in one call.
+func (c *CachedStore) GetMany(ctx context.Context, ids []string) ([]*pb.Product, error) {
+	result := make([]*pb.Product, 0, len(ids))
+	var missed []string
+	for _, id := range ids {
+		if p, ok := c.lookup(cacheKey(ctx, id)); ok {
+			c.hits.Add(1)
+			result = append(result, p)
+			continue
+		}
+		c.misses.Add(1)
+		missed = append(missed, id)
+	}
+	if len(missed) == 0 {
+		return result, nil
+	}
+	gens := make([]uint64, len(missed))
+	for i, id := range missed {
+		gens[i] = c.startLoad(cacheKey(ctx, id))
+	}
+	products, err := c.ProductStore.GetMany(ctx, missed)
+	found := make(map[string]*pb.Product, len(products))
+	for _, p := range products {
+		found[p.Id] = p
+	}
+	for i, id := range missed {
+		c.finishLoad(cacheKey(ctx, id), gens[i], found[id])
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(result, products...), nil
+}
+
+func (c *CachedStore) Add(ctx context.Context, p *pb.Product) error {
+	defer c.invalidate(cacheKey(ctx, p.Id))
+	return c.ProductStore.Add(ctx, p)
+}
+
+func (c *CachedStore) AddMany(ctx context.Context, ps []*pb.Product) ([]error, error) {
+	defer func() {
+		for _, p := range ps {
+			c.invalidate(cacheKey(ctx, p.Id))
+		}
+	}()
+	return c.ProductStore.AddMany(ctx, ps)
+}
+
+func (c *CachedStore) Update(ctx context.Context, p *pb.Product) error {
+	defer c.invalidate(cacheKey(ctx, p.Id))
+	return c.ProductStore.Update(ctx, p)
+}
+
+func (c *CachedStore) Delete(ctx context.Context, id string) error {
+	defer c.invalidate(cacheKey(ctx, id))
+	return c.ProductStore.Delete(ctx, id)
+}
+
+func (c *CachedStore) Restore(ctx context.Context, id string) (*pb.Product, error) {
+	defer c.invalidate(cacheKey(ctx, id))
+	return c.ProductStore.Restore(ctx, id)
+}
+
+func (c *CachedStore) Purge(ctx context.Context, before time.Time) ([]PurgedProduct, error) {
+	purged, err := c.ProductStore.Purge(ctx, before)
+	for _, p := range purged {
+		c.invalidate(cacheKey(WithTenant(ctx, p.Tenant), p.Product.Id))
+	}
+	return purged, err
+}
+
+// Invalidate drops a product changed without going through the cache.
+func (c *CachedStore) Invalidate(ctx context.Context, id string) {
+	c.invalidate(cacheKey(ctx, id))
+}
+
+func (c *CachedStore) Stats() CacheStats {
+	c.mu.Lock()
+	size := c.ll.Len()
+	c.mu.Unlock()
+	return CacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		Size:      size,
+	}
+}
+
+func cacheKey(ctx context.Context, id string) string {
+	return TenantFromContext(ctx) + "/" + id
+}
+
+func (c *CachedStore) lookup(key string) (*pb.Product, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*cacheEntry)
+	if time.Now().After(e.expires) {
+		c.ll.Remove(el)
+		delete(c.items, key)
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return proto.Clone(e.product).(*pb.Product), true
+}
+
+// startLoad registers a backend read of key and returns the generation
+// to pass to finishLoad.
+func (c *CachedStore) startLoad(key string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l, ok := c.loads[key]
+	if !ok {
+		l = &pendingLoad{}
+		c.loads[key] = l
+	}
+	l.refs++
+	return l.gen
+}
+
+// finishLoad ends a read started by startLoad and caches p unless key was
+// invalidated in the meantime. A nil p only ends the read.
+func (c *CachedStore) finishLoad(key string, gen uint64, p *pb.Product) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l := c.loads[key]
+	if l.refs--; l.refs == 0 {
+		delete(c.loads, key)
+	}
+	if p != nil && l.gen == gen {
+		c.put(key, p)
+	}
+}
+
+// put caches p under key.
+// c.mu must be held.
+func (c *CachedStore) put(key string, p *pb.Product) {
+	e := &cacheEntry{key: key, product: proto.Clone(p).(*pb.Product), expires: time.Now().Add(c.ttl)}
+	if el, ok := c.items[key]; ok {
+		el.Value = e
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.ll.PushFront(e)
+	for c.ll.Len() > c.size {
+		last := c.ll.Back()
+		c.ll.Remove(last)
+		delete(c.items, last.Value.(*cacheEntry).key)
+		c.evictions.Add(1)
+	}
+}
+
+func (c *CachedStore) invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if l, ok := c.loads[key]; ok {
+		l.gen++
+	}
+	if el, ok := c.items[key]; ok {
+		c.ll.Remove(el)
+		delete(c.items, key)
+	}
+}
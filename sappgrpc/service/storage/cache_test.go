@@ -0,0 +1,295 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "service/sappgrpc"
+)
+
+// blockingStore counts backend reads and holds each one until release is
+// closed. started receives the id of every read that has been served by
+// the backend.
+type blockingStore struct {
+	*MemoryStore
+	gets    atomic.Int32
+	started chan string
+	release chan struct{}
+}
+
+func newBlockingStore() *blockingStore {
+	return &blockingStore{
+		MemoryStore: NewMemoryStore(),
+		started:     make(chan string, 16),
+		release:     make(chan struct{}),
+	}
+}
+
+func (s *blockingStore) Get(ctx context.Context, id string) (*pb.Product, error) {
+	p, err := s.MemoryStore.Get(ctx, id)
+	s.gets.Add(1)
+	s.started <- id
+	<-s.release
+	return p, err
+}
+
+// countingStore counts backend reads.
+type countingStore struct {
+	*MemoryStore
+	gets atomic.Int32
+}
+
+func (s *countingStore) Get(ctx context.Context, id string) (*pb.Product, error) {
+	s.gets.Add(1)
+	return s.MemoryStore.Get(ctx, id)
+}
+
+func addProducts(t *testing.T, store ProductStore, ctx context.Context, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		if err := store.Add(ctx, &pb.Product{Id: id, Name: "Product " + id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func cacheGet(t *testing.T, c *CachedStore, ctx context.Context, id string) *pb.Product {
+	t.Helper()
+	p, err := c.Get(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestCachedStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	backend := &countingStore{MemoryStore: NewMemoryStore()}
+	c := NewCachedStore(backend, 2, time.Minute)
+	ctx := tenantContext("acme")
+	addProducts(t, backend, ctx, "a", "b", "c")
+
+	cacheGet(t, c, ctx, "a")
+	cacheGet(t, c, ctx, "b")
+	cacheGet(t, c, ctx, "a")
+	cacheGet(t, c, ctx, "c")
+	if got := backend.gets.Load(); got != 3 {
+		t.Fatalf("got %d backend reads, want 3", got)
+	}
+	cacheGet(t, c, ctx, "a")
+	cacheGet(t, c, ctx, "b")
+	if got := backend.gets.Load(); got != 4 {
+		t.Fatalf("got %d backend reads, want b to be the only one evicted", got)
+	}
+	stats := c.Stats()
+	if stats.Hits != 2 || stats.Misses != 4 || stats.Evictions != 2 || stats.Size != 2 {
+		t.Fatalf("got stats %+v", stats)
+	}
+}
+
+func TestCachedStoreExpiresEntries(t *testing.T) {
+	backend := &countingStore{MemoryStore: NewMemoryStore()}
+	c := NewCachedStore(backend, 10, 20*time.Millisecond)
+	ctx := tenantContext("acme")
+	addProducts(t, backend, ctx, "a")
+
+	cacheGet(t, c, ctx, "a")
+	cacheGet(t, c, ctx, "a")
+	if got := backend.gets.Load(); got != 1 {
+		t.Fatalf("got %d backend reads before expiry, want 1", got)
+	}
+	time.Sleep(30 * time.Millisecond)
+	cacheGet(t, c, ctx, "a")
+	if got := backend.gets.Load(); got != 2 {
+		t.Fatalf("got %d backend reads after expiry, want 2", got)
+	}
+}
+
+func TestCachedStoreKeysByTenant(t *testing.T) {
+	backend := NewMemoryStore()
+	c := NewCachedStore(backend, 10, time.Minute)
+	ctx := tenantContext("acme")
+	addProducts(t, backend, ctx, "a")
+
+	cacheGet(t, c, ctx, "a")
+	if _, err := c.Get(tenantContext("globex"), "a"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("got %v for another tenant, want ErrNotFound", err)
+	}
+	other := tenantContext("globex")
+	if err := backend.Add(other, &pb.Product{Id: "a", Name: "Globex a"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := cacheGet(t, c, other, "a"); got.Name != "Globex a" {
+		t.Fatalf("got %q for globex", got.Name)
+	}
+	if got := cacheGet(t, c, ctx, "a"); got.Name != "Product a" {
+		t.Fatalf("got %q for acme", got.Name)
+	}
+}
+
+func TestCachedStoreInvalidatesOnWrite(t *testing.T) {
+	backend := NewMemoryStore()
+	c := NewCachedStore(backend, 10, time.Minute)
+	ctx := tenantContext("acme")
+	addProducts(t, c, ctx, "a")
+
+	p := cacheGet(t, c, ctx, "a")
+	p.Name = "Renamed"
+	if err := c.Update(ctx, p); err != nil {
+		t.Fatal(err)
+	}
+	if got := cacheGet(t, c, ctx, "a"); got.Name != "Renamed" {
+		t.Fatalf("got name %q after update", got.Name)
+	}
+	if err := c.Delete(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, "a"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("got %v after delete, want ErrNotFound", err)
+	}
+}
+
+func TestCachedStoreCoalescesMisses(t *testing.T) {
+	backend := newBlockingStore()
+	c := NewCachedStore(backend, 10, time.Minute)
+	ctx := tenantContext("acme")
+	addProducts(t, backend, ctx, "a")
+
+	const callers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Get(ctx, "a")
+			errs <- err
+		}()
+	}
+	<-backend.started
+	for c.Stats().Misses < callers {
+		time.Sleep(time.Millisecond)
+	}
+	close(backend.release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := backend.gets.Load(); got != 1 {
+		t.Fatalf("got %d backend reads, want 1", got)
+	}
+}
+
+func TestCachedStoreSharedLoadOutlivesCaller(t *testing.T) {
+	backend := newBlockingStore()
+	c := NewCachedStore(backend, 10, time.Minute)
+	ctx := tenantContext("acme")
+	addProducts(t, backend, ctx, "a")
+
+	cctx, cancel := context.WithCancel(ctx)
+	first := make(chan error, 1)
+	go func() {
+		_, err := c.Get(cctx, "a")
+		first <- err
+	}()
+	<-backend.started
+	second := make(chan error, 1)
+	go func() {
+		_, err := c.Get(ctx, "a")
+		second <- err
+	}()
+	for c.Stats().Misses < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v for the canceled caller", err)
+	}
+	close(backend.release)
+	if err := <-second; err != nil {
+		t.Fatalf("got %v for the waiting caller", err)
+	}
+}
+
+func TestCachedStoreDropsStaleLoad(t *testing.T) {
+	backend := newBlockingStore()
+	c := NewCachedStore(backend, 10, time.Minute)
+	ctx := tenantContext("acme")
+	addProducts(t, backend, ctx, "a", "b")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Get(ctx, "a")
+	}()
+	<-backend.started
+
+	// A write of another product must not drop the load of a.
+	b, err := backend.MemoryStore.Get(ctx, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Update(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+	close(backend.release)
+	<-done
+	if got := c.Stats().Size; got != 1 {
+		t.Fatalf("got %d cached products, want the load of a cached", got)
+	}
+
+	// A write of a while it is read must keep the old product out.
+	backend.release = make(chan struct{})
+	c.Invalidate(ctx, "a")
+	done = make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Get(ctx, "a")
+	}()
+	<-backend.started
+	a, err := backend.MemoryStore.Get(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Name = "Renamed"
+	if err := c.Update(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	close(backend.release)
+	<-done
+	if got := c.Stats().Size; got != 0 {
+		t.Fatalf("got %d cached products, want the stale load dropped", got)
+	}
+	if len(c.loads) != 0 {
+		t.Fatalf("got %d pending loads after all reads finished", len(c.loads))
+	}
+}
+
+func TestCachedStoreGetMany(t *testing.T) {
+	backend := &countingStore{MemoryStore: NewMemoryStore()}
+	c := NewCachedStore(backend, 10, time.Minute)
+	ctx := tenantContext("acme")
+	addProducts(t, backend, ctx, "a", "b")
+
+	cacheGet(t, c, ctx, "a")
+	products, err := c.GetMany(ctx, []string{"a", "b", "missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(products) != 2 {
+		t.Fatalf("got %d products, want 2", len(products))
+	}
+	cacheGet(t, c, ctx, "b")
+	if got := backend.gets.Load(); got != 1 {
+		t.Fatalf("got %d backend reads, want b cached by GetMany", got)
+	}
+	if len(c.loads) != 0 {
+		t.Fatalf("got %d pending loads after GetMany", len(c.loads))
+	}
+}
@@ -0,0 +1,265 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+
+	pb "service/sappgrpc"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// CategoryCollectionName is the MongoDB collection of the category tree.
+const CategoryCollectionName = "categories"
+
+var categorySegment = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// CategoryStore keeps the category tree of each tenant. Like ProductStore
+// every call acts on the tenant set on ctx.
+type CategoryStore interface {
+	// AddCategory fails with ErrCategoryExists for a known path.
+	AddCategory(ctx context.Context, c *pb.Category) error
+	GetCategory(ctx context.Context, path string) (*pb.Category, error)
+	// UpdateCategory replaces the display name of the category.
+	UpdateCategory(ctx context.Context, c *pb.Category) error
+	DeleteCategory(ctx context.Context, path string) error
+	// ListCategories returns the descendants of parent ordered by path,
+	// all of them or only the direct children.
+	ListCategories(ctx context.Context, parent string, recursive bool) ([]*pb.Category, error)
+}
+
+// ProductFilter narrows ListProducts and SearchProducts. The zero value
+// matches every product.
+type ProductFilter struct {
+	// Category matches the category and its whole subtree.
+	Category string
+	// Tags must all be carried by a product.
+	Tags []string
+	// ShowDeleted includes deleted products.
+	ShowDeleted bool
+}
+
+func newProductFilter(category string, tags []string) (ProductFilter, error) {
+	if category != "" {
+		if err := validateCategoryPath(category); err != nil {
+			return ProductFilter{}, err
+		}
+	}
+	return ProductFilter{Category: category, Tags: normalizeTags(tags)}, nil
+}
+
+func (f ProductFilter) match(p *pb.Product) bool {
+	if p.DeleteTime != nil && !f.ShowDeleted {
+		return false
+	}
+	if f.Category != "" && !inCategory(p.Category, f.Category) {
+		return false
+	}
+	for _, t := range f.Tags {
+		i := sort.SearchStrings(p.Tags, t)
+		if i == len(p.Tags) || p.Tags[i] != t {
+			return false
+		}
+	}
+	return true
+}
+
+func validateCategoryPath(path string) error {
+	for _, seg := range strings.Split(path, "/") {
+		if !categorySegment.MatchString(seg) {
+			return status.Errorf(codes.InvalidArgument, "Invalid category path: %q", path)
+		}
+	}
+	return nil
+}
+
+// inCategory reports whether path lies in the subtree of category.
+func inCategory(path, category string) bool {
+	return path == category || strings.HasPrefix(path, category+"/")
+}
+
+// parentCategory returns the parent path, or "" for a root category.
+func parentCategory(path string) string {
+	if i := strings.LastIndexByte(path, '/'); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+// normalizeTags lowercases tags and sorts them without duplicates or
+// empty entries. ProductFilter.match relies on the order.
+func normalizeTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t != "" && !seen[t] {
+			seen[t] = true
+			result = append(result, t)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// facetCounter counts products per category and tag.
+type facetCounter struct {
+	categories map[string]int64
+	tags       map[string]int64
+}
+
+func newFacetCounter() *facetCounter {
+	return &facetCounter{categories: make(map[string]int64), tags: make(map[string]int64)}
+}
+
+// addCategory counts n products for category and all of its ancestors.
+func (f *facetCounter) addCategory(category string, n int64) {
+	for category != "" {
+		f.categories[category] += n
+		category = parentCategory(category)
+	}
+}
+
+func (f *facetCounter) add(p *pb.Product) {
+	f.addCategory(p.Category, 1)
+	for _, t := range p.Tags {
+		f.tags[t]++
+	}
+}
+
+func (f *facetCounter) result() (categories, tags []*pb.FacetCount) {
+	return sortFacets(f.categories), sortFacets(f.tags)
+}
+
+func sortFacets(counts map[string]int64) []*pb.FacetCount {
+	facets := make([]*pb.FacetCount, 0, len(counts))
+	for v, n := range counts {
+		facets = append(facets, &pb.FacetCount{Value: v, Count: n})
+	}
+	sort.Slice(facets, func(i, j int) bool {
+		if facets[i].Count != facets[j].Count {
+			return facets[i].Count > facets[j].Count
+		}
+		return facets[i].Value < facets[j].Value
+	})
+	return facets
+}
+
+// checkCategory normalizes the tags of a product being written and makes
+// sure its category exists.
+func (c *ProductService) checkCategory(ctx context.Context, p *pb.Product) error {
+	p.Tags = normalizeTags(p.Tags)
+	if p.Category == "" {
+		return nil
+	}
+	if err := validateCategoryPath(p.Category); err != nil {
+		return err
+	}
+	if c.categories == nil {
+		return nil
+	}
+	_, err := c.categories.GetCategory(ctx, p.Category)
+	if errors.Is(err, ErrCategoryNotFound) {
+		return status.Errorf(codes.FailedPrecondition, "Category %s does not exist", p.Category)
+	}
+	if err != nil {
+		return resourceStatus(err, categoryResource, "get", p.Category)
+	}
+	return nil
+}
+
+func (c *ProductService) CreateCategory(ctx context.Context, req *pb.Category) (*pb.Category, error) {
+	if c.categories == nil {
+		return nil, status.Errorf(codes.Unimplemented, "Categories are not configured")
+	}
+	if err := validateCategoryPath(req.Path); err != nil {
+		return nil, err
+	}
+	if parent := parentCategory(req.Path); parent != "" {
+		_, err := c.categories.GetCategory(ctx, parent)
+		if errors.Is(err, ErrCategoryNotFound) {
+			return nil, status.Errorf(codes.FailedPrecondition, "Parent category %s does not exist", parent)
+		}
+		if err != nil {
+			return nil, resourceStatus(err, categoryResource, "get", parent)
+		}
+	}
+	cat := &pb.Category{Path: req.Path, DisplayName: req.DisplayName}
+	if err := c.categories.AddCategory(ctx, cat); err != nil {
+		return nil, resourceStatus(err, categoryResource, "create", req.Path)
+	}
+	return cat, nil
+}
+
+func (c *ProductService) GetCategory(ctx context.Context, req *pb.CategoryPath) (*pb.Category, error) {
+	if c.categories == nil {
+		return nil, status.Errorf(codes.Unimplemented, "Categories are not configured")
+	}
+	cat, err := c.categories.GetCategory(ctx, req.Path)
+	if err != nil {
+		return nil, resourceStatus(err, categoryResource, "get", req.Path)
+	}
+	return cat, nil
+}
+
+func (c *ProductService) UpdateCategory(ctx context.Context, req *pb.Category) (*pb.Category, error) {
+	if c.categories == nil {
+		return nil, status.Errorf(codes.Unimplemented, "Categories are not configured")
+	}
+	cat := &pb.Category{Path: req.Path, DisplayName: req.DisplayName}
+	if err := c.categories.UpdateCategory(ctx, cat); err != nil {
+		return nil, resourceStatus(err, categoryResource, "update", req.Path)
+	}
+	return cat, nil
+}
+
+// DeleteCategory only removes leaf categories without products. Deleted
+// products count until they are purged, since RestoreProduct brings them
+// back into their category.
+func (c *ProductService) DeleteCategory(ctx context.Context, req *pb.CategoryPath) (*emptypb.Empty, error) {
+	if c.categories == nil {
+		return nil, status.Errorf(codes.Unimplemented, "Categories are not configured")
+	}
+	children, err := c.categories.ListCategories(ctx, req.Path, false)
+	if err != nil {
+		return nil, resourceStatus(err, categoryResource, "delete", req.Path)
+	}
+	if len(children) > 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "Category %s has subcategories", req.Path)
+	}
+	products, err := c.Store.List(ctx, ProductFilter{Category: req.Path, ShowDeleted: true}, "", 1)
+	if err != nil {
+		return nil, toStatus(err, "list", "")
+	}
+	if len(products) > 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "Category %s still has products", req.Path)
+	}
+	if err := c.categories.DeleteCategory(ctx, req.Path); err != nil {
+		return nil, resourceStatus(err, categoryResource, "delete", req.Path)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (c *ProductService) ListCategories(ctx context.Context, req *pb.ListCategoriesRequest) (*pb.ListCategoriesResponse, error) {
+	if c.categories == nil {
+		return nil, status.Errorf(codes.Unimplemented, "Categories are not configured")
+	}
+	if req.Parent != "" {
+		if err := validateCategoryPath(req.Parent); err != nil {
+			return nil, err
+		}
+	}
+	cats, err := c.categories.ListCategories(ctx, req.Parent, req.Recursive)
+	if err != nil {
+		return nil, resourceStatus(err, categoryResource, "list", req.Parent)
+	}
+	return &pb.ListCategoriesResponse{Categories: cats}, nil
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	pb "service/sappgrpc"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestFacetCounterRollsUpCategories(t *testing.T) {
+	counter := newFacetCounter()
+	for _, p := range []*pb.Product{
+		{Category: "home/kitchen/kettles", Tags: []string{"steel"}},
+		{Category: "home/kitchen", Tags: []string{"steel", "glass"}},
+		{Category: "garden"},
+		{},
+	} {
+		counter.add(p)
+	}
+	categories, tags := counter.result()
+
+	wantCategories := []*pb.FacetCount{
+		{Value: "home", Count: 2},
+		{Value: "home/kitchen", Count: 2},
+		{Value: "garden", Count: 1},
+		{Value: "home/kitchen/kettles", Count: 1},
+	}
+	wantTags := []*pb.FacetCount{
+		{Value: "steel", Count: 2},
+		{Value: "glass", Count: 1},
+	}
+	for _, tt := range []struct {
+		name      string
+		got, want []*pb.FacetCount
+	}{
+		{"categories", categories, wantCategories},
+		{"tags", tags, wantTags},
+	} {
+		if len(tt.got) != len(tt.want) {
+			t.Fatalf("got %s %v, want %v", tt.name, tt.got, tt.want)
+		}
+		for i := range tt.want {
+			if tt.got[i].Value != tt.want[i].Value || tt.got[i].Count != tt.want[i].Count {
+				t.Fatalf("got %s %v, want %v", tt.name, tt.got, tt.want)
+			}
+		}
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	got := normalizeTags([]string{" Steel", "glass", "steel", ""})
+	if len(got) != 2 || got[0] != "glass" || got[1] != "steel" {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestDeleteCategoryKeepsCategoriesOfDeletedProducts(t *testing.T) {
+	m := NewMemoryStore()
+	c := NewProductService(m, WithCategories(m))
+	ctx := tenantContext("acme")
+	for _, path := range []string{"home", "home/kitchen"} {
+		if _, err := c.CreateCategory(ctx, &pb.Category{Path: path}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	id, err := c.AddProduct(ctx, &pb.Product{Name: "Kettle", Category: "home/kitchen"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.DeleteCategory(ctx, &pb.CategoryPath{Path: "home"})
+	wantStatus(t, err, codes.FailedPrecondition, "")
+	_, err = c.DeleteCategory(ctx, &pb.CategoryPath{Path: "home/kitchen"})
+	wantStatus(t, err, codes.FailedPrecondition, "")
+
+	// A deleted product may still be restored into its category.
+	if _, err := c.DeleteProduct(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.DeleteCategory(ctx, &pb.CategoryPath{Path: "home/kitchen"})
+	wantStatus(t, err, codes.FailedPrecondition, "")
+
+	if _, err := m.Purge(ctx, now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.DeleteCategory(ctx, &pb.CategoryPath{Path: "home/kitchen"}); err != nil {
+		t.Fatalf("got %v after the product was purged", err)
+	}
+}
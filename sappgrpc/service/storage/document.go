@@ -0,0 +1,241 @@
+package storage
+
+import (
+	"strconv"
+	"time"
+
+	pb "service/sappgrpc"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// schemaVersion is written into every stored product document. Documents
+// inserted before versioning was introduced have no schema_version and
+// decode as version 0.
+const schemaVersion = 1
+
+// productDocument is the MongoDB representation of a product. It is kept
+// separate from pb.Product so the proto can evolve without touching the
+// stored schema.
+type productDocument struct {
+	ID          string `bson:"id"`
+	Tenant      string `bson:"tenant"`
+	Name        string `bson:"name"`
+	Description string `bson:"description"`
+	// Translations is keyed by BCP 47 language tag.
+	Translations  map[string]translationDocument `bson:"translations,omitempty"`
+	Category      string                         `bson:"category,omitempty"`
+	Tags          []string                       `bson:"tags,omitempty"`
+	Price         *moneyDocument                 `bson:"price,omitempty"`
+	SchemaVersion int                            `bson:"schema_version"`
+	Version       int64                          `bson:"version"`
+	CreatedAt     time.Time                      `bson:"created_at,omitempty"`
+	UpdatedAt     time.Time                      `bson:"updated_at,omitempty"`
+	DeletedAt     time.Time                      `bson:"deleted_at,omitempty"`
+}
+
+type translationDocument struct {
+	Name        string `bson:"name"`
+	Description string `bson:"description"`
+}
+
+func newProductDocument(p *pb.Product) productDocument {
+	return productDocument{
+		ID:            p.Id,
+		Name:          p.Name,
+		Description:   p.Description,
+		Translations:  newTranslationDocuments(p.Translations),
+		Category:      p.Category,
+		Tags:          p.Tags,
+		SchemaVersion: schemaVersion,
+		CreatedAt:     fromTimestamp(p.CreateTime),
+		UpdatedAt:     fromTimestamp(p.UpdateTime),
+	}
+}
+
+func newTranslationDocuments(ts map[string]*pb.Translation) map[string]translationDocument {
+	if len(ts) == 0 {
+		return nil
+	}
+	docs := make(map[string]translationDocument, len(ts))
+	for tag, t := range ts {
+		docs[tag] = translationDocument{Name: t.GetName(), Description: t.GetDescription()}
+	}
+	return docs
+}
+
+func (d productDocument) toProto() *pb.Product {
+	p := &pb.Product{
+		Id:          d.ID,
+		Name:        d.Name,
+		Description: d.Description,
+		CreateTime:  toTimestamp(d.CreatedAt),
+		UpdateTime:  toTimestamp(d.UpdatedAt),
+		Etag:        formatETag(d.Version),
+		Category:    d.Category,
+		Tags:        d.Tags,
+		Price:       d.Price.toProto(),
+		DeleteTime:  toTimestamp(d.DeletedAt),
+	}
+	if len(d.Translations) > 0 {
+		p.Translations = make(map[string]*pb.Translation, len(d.Translations))
+		for tag, t := range d.Translations {
+			p.Translations[tag] = &pb.Translation{Name: t.Name, Description: t.Description}
+		}
+	}
+	return p
+}
+
+// formatETag and parseETag convert between the stored write counter of a
+// product and its etag.
+func formatETag(version int64) string {
+	return strconv.FormatInt(version, 10)
+}
+
+func parseETag(etag string) (int64, error) {
+	return strconv.ParseInt(etag, 10, 64)
+}
+
+// now returns the current time truncated to the millisecond precision
+// of BSON dates, so stored and returned timestamps agree.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func fromTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+// categoryDocument is the MongoDB representation of a category.
+type categoryDocument struct {
+	Tenant      string    `bson:"tenant"`
+	Path        string    `bson:"path"`
+	DisplayName string    `bson:"display_name"`
+	CreatedAt   time.Time `bson:"created_at"`
+}
+
+func (d categoryDocument) toProto() *pb.Category {
+	return &pb.Category{
+		Path:        d.Path,
+		DisplayName: d.DisplayName,
+		CreateTime:  toTimestamp(d.CreatedAt),
+	}
+}
+
+// variantDocument is the MongoDB representation of a variant.
+type variantDocument struct {
+	Tenant     string            `bson:"tenant"`
+	ID         string            `bson:"id"`
+	ProductID  string            `bson:"product_id"`
+	SKU        string            `bson:"sku"`
+	Attributes map[string]string `bson:"attributes,omitempty"`
+	CreatedAt  time.Time         `bson:"created_at"`
+	UpdatedAt  time.Time         `bson:"updated_at"`
+}
+
+func (d variantDocument) toProto() *pb.Variant {
+	return &pb.Variant{
+		Id:         d.ID,
+		ProductId:  d.ProductID,
+		Sku:        d.SKU,
+		Attributes: d.Attributes,
+		CreateTime: toTimestamp(d.CreatedAt),
+		UpdateTime: toTimestamp(d.UpdatedAt),
+	}
+}
+
+type moneyDocument struct {
+	CurrencyCode string `bson:"currency_code"`
+	Units        int64  `bson:"units"`
+	Nanos        int32  `bson:"nanos"`
+}
+
+func newMoneyDocument(m *pb.Money) moneyDocument {
+	return moneyDocument{CurrencyCode: m.GetCurrencyCode(), Units: m.GetUnits(), Nanos: m.GetNanos()}
+}
+
+func (d *moneyDocument) toProto() *pb.Money {
+	if d == nil {
+		return nil
+	}
+	return &pb.Money{CurrencyCode: d.CurrencyCode, Units: d.Units, Nanos: d.Nanos}
+}
+
+// priceDocument is the MongoDB representation of a price change.
+type priceDocument struct {
+	Tenant      string        `bson:"tenant"`
+	ID          string        `bson:"id"`
+	ProductID   string        `bson:"product_id"`
+	Price       moneyDocument `bson:"price"`
+	EffectiveAt time.Time     `bson:"effective_at"`
+	CreatedAt   time.Time     `bson:"created_at"`
+	Applied     bool          `bson:"applied"`
+}
+
+func (d priceDocument) toProto() *pb.PriceChange {
+	return &pb.PriceChange{
+		Id:            d.ID,
+		ProductId:     d.ProductID,
+		Price:         d.Price.toProto(),
+		EffectiveTime: toTimestamp(d.EffectiveAt),
+		CreateTime:    toTimestamp(d.CreatedAt),
+		Applied:       d.Applied,
+	}
+}
+
+// auditDocument is the MongoDB representation of an audit event. The
+// product is kept as a productDocument so the trail reads like the
+// products collection.
+type auditDocument struct {
+	Tenant        string          `bson:"tenant"`
+	ID            string          `bson:"id"`
+	ProductID     string          `bson:"product_id"`
+	Action        string          `bson:"action"`
+	Actor         string          `bson:"actor"`
+	Time          time.Time       `bson:"time"`
+	ChangedFields []string        `bson:"changed_fields,omitempty"`
+	Product       productDocument `bson:"product"`
+}
+
+func newAuditDocument(e *pb.AuditEvent) auditDocument {
+	p := newProductDocument(e.Product)
+	if e.Product.Price != nil {
+		price := newMoneyDocument(e.Product.Price)
+		p.Price = &price
+	}
+	p.DeletedAt = fromTimestamp(e.Product.DeleteTime)
+	return auditDocument{
+		ID:            e.Id,
+		ProductID:     e.ProductId,
+		Action:        e.Action.String(),
+		Actor:         e.Actor,
+		Time:          fromTimestamp(e.EventTime),
+		ChangedFields: e.ChangedFields,
+		Product:       p,
+	}
+}
+
+func (d auditDocument) toProto() *pb.AuditEvent {
+	p := d.Product.toProto()
+	// Snapshots carry no version.
+	p.Etag = ""
+	return &pb.AuditEvent{
+		Id:            d.ID,
+		ProductId:     d.ProductID,
+		Action:        pb.AuditEvent_Action(pb.AuditEvent_Action_value[d.Action]),
+		Actor:         d.Actor,
+		EventTime:     toTimestamp(d.Time),
+		ChangedFields: d.ChangedFields,
+		Product:       p,
+	}
+}
@@ -0,0 +1,314 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	pb "service/sappgrpc"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// DuplicatePolicy is what AddProduct does with a product that duplicates
+// a stored one.
+type DuplicatePolicy string
+
+const (
+	// DuplicatesWarn adds the product and names the stored one in the
+	// duplicate-of and warning trailers.
+	DuplicatesWarn DuplicatePolicy = "warn"
+	// DuplicatesReject fails with ALREADY_EXISTS naming the stored
+	// product.
+	DuplicatesReject DuplicatePolicy = "reject"
+)
+
+const DefaultDuplicateThreshold = 0.9
+
+const (
+	duplicateOfTrailer = "duplicate-of"
+	warningTrailer     = "warning"
+)
+
+var errDuplicate = errors.New("a product with a similar name and description exists")
+
+// fingerprint is what duplicate detection compares of a product.
+type fingerprint struct {
+	name        string
+	nameGrams   map[string]bool
+	descGrams   map[string]bool
+	description bool
+}
+
+func newFingerprint(p *pb.Product) fingerprint {
+	name := normalizeName(p.Name)
+	desc := normalizeName(p.Description)
+	return fingerprint{
+		name:        name,
+		nameGrams:   trigrams(name),
+		descGrams:   trigrams(desc),
+		description: desc != "",
+	}
+}
+
+// normalizeName ignores case, punctuation and spacing.
+func normalizeName(s string) string {
+	return strings.Join(tokenize(s), " ")
+}
+
+// trigrams returns the rune trigrams of a normalized string padded with
+// spaces, so short words still have some.
+func trigrams(s string) map[string]bool {
+	grams := make(map[string]bool)
+	if s == "" {
+		return grams
+	}
+	r := []rune(" " + s + " ")
+	for i := 0; i+3 <= len(r); i++ {
+		grams[string(r[i:i+3])] = true
+	}
+	return grams
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for g := range a {
+		if b[g] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// similarity weighs names and descriptions like search ranking does. If
+// neither product has a description only the names are compared.
+func (f fingerprint) similarity(g fingerprint) float64 {
+	name := jaccard(f.nameGrams, g.nameGrams)
+	if !f.description && !g.description {
+		return name
+	}
+	desc := jaccard(f.descGrams, g.descGrams)
+	return (nameWeight*name + descriptionWeight*desc) / (nameWeight + descriptionWeight)
+}
+
+type duplicate struct {
+	product    *pb.Product
+	similarity float64
+	exact      bool
+}
+
+// better prefers exact name matches, then higher similarity.
+func (d duplicate) better(o duplicate) bool {
+	if d.exact != o.exact {
+		return d.exact
+	}
+	return d.similarity > o.similarity
+}
+
+// duplicateOf returns the stored product p duplicates best, or nil. Only
+// the products found by searching for the name of p are compared.
+func (c *ProductService) duplicateOf(ctx context.Context, p *pb.Product) (*duplicate, error) {
+	fp := newFingerprint(p)
+	if fp.name == "" {
+		return nil, nil
+	}
+	results, err := c.Store.Search(ctx, fp.name, false, ProductFilter{}, maxPageSize)
+	if err != nil {
+		return nil, err
+	}
+	var best *duplicate
+	for _, r := range results {
+		other := newFingerprint(r.Product)
+		d := duplicate{product: r.Product, similarity: fp.similarity(other), exact: fp.name == other.name}
+		if !d.exact && d.similarity < c.duplicateThreshold {
+			continue
+		}
+		if best == nil || d.better(*best) {
+			best = &d
+		}
+	}
+	return best, nil
+}
+
+func duplicateStatus(d *duplicate) error {
+	msg := fmt.Sprintf("Product looks like a duplicate of %s (similarity %.2f)", d.product.Id, d.similarity)
+	return withDetails(status.New(codes.AlreadyExists, msg), errDuplicate, "PRODUCT_DUPLICATE", "add", "sappgrpc.Product", d.product.Id)
+}
+
+// warnDuplicate tells the client about an added duplicate in the
+// trailers of the call.
+func warnDuplicate(ctx context.Context, d *duplicate) {
+	grpc.SetTrailer(ctx, metadata.Pairs(
+		duplicateOfTrailer, d.product.Id,
+		warningTrailer, fmt.Sprintf("Product looks like a duplicate of %s (similarity %.2f)", d.product.Id, d.similarity),
+	))
+}
+
+// FindDuplicates scans the catalog of the tenant for groups of duplicate
+// products. Only products sharing a word in their names are compared. The
+// catalog is paged through keeping only fingerprints, the products of the
+// groups found are read again at the end.
+func (c *ProductService) FindDuplicates(ctx context.Context, req *pb.FindDuplicatesRequest) (*pb.FindDuplicatesResponse, error) {
+	threshold := req.Threshold
+	if threshold == 0 {
+		threshold = c.duplicateThreshold
+	}
+	if threshold < 0 || threshold > 1 {
+		return nil, status.Errorf(codes.InvalidArgument, "Threshold must be between 0 and 1")
+	}
+	var ids []string
+	var fps []fingerprint
+	byWord := make(map[string][]int)
+	groups := newDuplicateGroups(0)
+	for after := ""; ; {
+		batch, err := c.Store.List(ctx, ProductFilter{}, after, exportBatchSize)
+		if err != nil {
+			return nil, toStatus(err, "list", "")
+		}
+		for _, p := range batch {
+			i := groups.add()
+			fp := newFingerprint(p)
+			ids = append(ids, p.Id)
+			fps = append(fps, fp)
+			seen := make(map[int]bool)
+			for _, w := range strings.Fields(fp.name) {
+				for _, j := range byWord[w] {
+					if seen[j] {
+						continue
+					}
+					seen[j] = true
+					sim := fps[j].similarity(fp)
+					if fps[j].name == fp.name || sim >= threshold {
+						groups.join(j, i, sim)
+					}
+				}
+			}
+			for _, w := range strings.Fields(fp.name) {
+				if ws := byWord[w]; len(ws) == 0 || ws[len(ws)-1] != i {
+					byWord[w] = append(ws, i)
+				}
+			}
+		}
+		if len(batch) < exportBatchSize {
+			break
+		}
+		after = batch[len(batch)-1].Id
+	}
+
+	sets := groups.sets()
+	var members []string
+	for _, set := range sets {
+		for _, i := range set {
+			members = append(members, ids[i])
+		}
+	}
+	products := make(map[string]*pb.Product, len(members))
+	for len(members) > 0 {
+		n := min(len(members), exportBatchSize)
+		batch, err := c.Store.GetMany(ctx, members[:n])
+		if err != nil {
+			return nil, toStatus(err, "get", "")
+		}
+		for _, p := range batch {
+			products[p.Id] = p
+		}
+		members = members[n:]
+	}
+	res := &pb.FindDuplicatesResponse{}
+	for _, set := range sets {
+		g := &pb.DuplicateGroup{Exact: true, Similarity: groups.similarity[groups.find(set[0])]}
+		first := -1
+		for _, i := range set {
+			// Products deleted since they were listed are left out.
+			p, ok := products[ids[i]]
+			if !ok {
+				continue
+			}
+			if first < 0 {
+				first = i
+			}
+			g.Products = append(g.Products, p)
+			g.Exact = g.Exact && fps[i].name == fps[first].name
+		}
+		if len(g.Products) > 1 {
+			res.Groups = append(res.Groups, g)
+		}
+	}
+	return res, nil
+}
+
+// duplicateGroups is a union-find over product indexes that remembers the
+// lowest similarity that joined each group.
+type duplicateGroups struct {
+	parent     []int
+	similarity map[int]float64
+}
+
+func newDuplicateGroups(n int) *duplicateGroups {
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	return &duplicateGroups{parent: parent, similarity: make(map[int]float64)}
+}
+
+// add adds a group with one new member and returns its index.
+func (g *duplicateGroups) add() int {
+	g.parent = append(g.parent, len(g.parent))
+	return len(g.parent) - 1
+}
+
+func (g *duplicateGroups) find(i int) int {
+	for g.parent[i] != i {
+		g.parent[i] = g.parent[g.parent[i]]
+		i = g.parent[i]
+	}
+	return g.parent[i]
+}
+
+func (g *duplicateGroups) join(i, j int, sim float64) {
+	ri, rj := g.find(i), g.find(j)
+	if ri == rj {
+		return
+	}
+	low := sim
+	for _, r := range []int{ri, rj} {
+		if s, ok := g.similarity[r]; ok && s < low {
+			low = s
+		}
+	}
+	delete(g.similarity, ri)
+	delete(g.similarity, rj)
+	if ri < rj {
+		g.parent[rj] = ri
+	} else {
+		g.parent[ri] = rj
+	}
+	g.similarity[g.find(i)] = low
+}
+
+// sets returns the groups with more than one member. Members are in index
+// order, which is id order since products are listed by id.
+func (g *duplicateGroups) sets() [][]int {
+	byRoot := make(map[int][]int)
+	for i := range g.parent {
+		r := g.find(i)
+		byRoot[r] = append(byRoot[r], i)
+	}
+	var sets [][]int
+	for _, members := range byRoot {
+		if len(members) > 1 {
+			sets = append(sets, members)
+		}
+	}
+	sort.Slice(sets, func(a, b int) bool { return sets[a][0] < sets[b][0] })
+	return sets
+}
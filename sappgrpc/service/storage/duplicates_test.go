@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+
+	pb "service/sappgrpc"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestDuplicateGroups(t *testing.T) {
+	g := newDuplicateGroups(6)
+	g.join(0, 1, 0.95)
+	g.join(3, 4, 0.92)
+	g.join(4, 1, 0.91)
+	g.join(0, 4, 0.99)
+
+	sets := g.sets()
+	if len(sets) != 1 {
+		t.Fatalf("got sets %v, want one", sets)
+	}
+	want := []int{0, 1, 3, 4}
+	if len(sets[0]) != len(want) {
+		t.Fatalf("got set %v, want %v", sets[0], want)
+	}
+	for i := range want {
+		if sets[0][i] != want[i] {
+			t.Fatalf("got set %v, want %v", sets[0], want)
+		}
+	}
+	if sim := g.similarity[g.find(0)]; sim != 0.91 {
+		t.Fatalf("got group similarity %v, want the lowest join 0.91", sim)
+	}
+}
+
+func TestFingerprintSimilarity(t *testing.T) {
+	a := newFingerprint(&pb.Product{Name: "Electric Kettle", Description: "1.7 l, steel"})
+	b := newFingerprint(&pb.Product{Name: "electric  KETTLE!", Description: "1.7 l, steel"})
+	if a.name != b.name || a.similarity(b) != 1 {
+		t.Fatalf("got names %q, %q with similarity %v", a.name, b.name, a.similarity(b))
+	}
+	c := newFingerprint(&pb.Product{Name: "Toaster"})
+	if sim := a.similarity(c); sim >= DefaultDuplicateThreshold {
+		t.Fatalf("unrelated products have similarity %v", sim)
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	m := NewMemoryStore()
+	c := NewProductService(m)
+	ctx := tenantContext("acme")
+	for _, p := range []*pb.Product{
+		{Id: "1", Name: "Electric Kettle"},
+		{Id: "2", Name: "Toaster"},
+		{Id: "3", Name: "electric kettle"},
+		{Id: "4", Name: "Electric Kettles"},
+	} {
+		if err := m.Add(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	res, err := c.FindDuplicates(ctx, &pb.FindDuplicatesRequest{Threshold: 0.7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Groups) != 1 || len(res.Groups[0].Products) != 3 || res.Groups[0].Exact {
+		t.Fatalf("got groups %v", res.Groups)
+	}
+	for i, id := range []string{"1", "3", "4"} {
+		if got := res.Groups[0].Products[i].Id; got != id {
+			t.Fatalf("product %d is %s, want %s", i, got, id)
+		}
+	}
+}
+
+func TestFindDuplicatesAcrossPages(t *testing.T) {
+	m := NewMemoryStore()
+	c := NewProductService(m)
+	ctx := tenantContext("acme")
+	n := exportBatchSize + exportBatchSize/2
+	for i := 0; i < n; i++ {
+		p := &pb.Product{Id: fmt.Sprintf("%04d", i), Name: fmt.Sprintf("Item%d", i)}
+		switch i {
+		case 0, n - 1:
+			p.Name = "Electric Kettle"
+		case 1:
+			p.Name = "Toaster"
+		}
+		if err := m.Add(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	res, err := c.FindDuplicates(ctx, &pb.FindDuplicatesRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Groups) != 1 || !res.Groups[0].Exact || len(res.Groups[0].Products) != 2 {
+		t.Fatalf("got groups %v", res.Groups)
+	}
+	if first, last := res.Groups[0].Products[0].Id, res.Groups[0].Products[1].Id; first != "0000" || last != fmt.Sprintf("%04d", n-1) {
+		t.Fatalf("got group of %s and %s", first, last)
+	}
+}
+
+func TestAddProductRejectsDuplicates(t *testing.T) {
+	c := NewProductService(NewMemoryStore(), WithDuplicateCheck(DuplicatesReject, DefaultDuplicateThreshold))
+	ctx := tenantContext("acme")
+	if _, err := c.AddProduct(ctx, &pb.Product{Name: "Electric Kettle", Description: "1.7 l, steel"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := c.AddProduct(ctx, &pb.Product{Name: "electric  kettle!", Description: "1.7 l, steel"})
+	wantStatus(t, err, codes.AlreadyExists, "PRODUCT_DUPLICATE")
+
+	if _, err := c.AddProduct(ctx, &pb.Product{Name: "Toaster"}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/x/mongo/driver/topology"
+	epb "google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const errorDomain = "sappgrpc.ProductInfo"
+
+var (
+	ErrAlreadyExists = errors.New("product already exists")
+	ErrConflict      = errors.New("product was modified concurrently")
+
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrCategoryExists   = errors.New("category already exists")
+
+	ErrVariantNotFound = errors.New("variant not found")
+	ErrSKUExists       = errors.New("sku is already used by another variant")
+
+	ErrPriceNotFound = errors.New("price change not found")
+	ErrPriceApplied  = errors.New("price change was already applied")
+	ErrNoPrice       = errors.New("product has no price")
+)
+
+// resourceKind describes how the store errors of one kind of resource are
+// reported to clients.
+type resourceKind struct {
+	// typ is the resource type of the ResourceInfo detail.
+	typ string
+	// noun names the resource in the message of unexpected errors.
+	noun  string
+	known []knownError
+}
+
+// knownError maps a store error to a status code and ErrorInfo reason.
+// message is formatted with the name of the resource.
+type knownError struct {
+	err error
+	// duplicateKey also matches MongoDB duplicate key errors.
+	duplicateKey bool
+	code         codes.Code
+	reason       string
+	message      string
+}
+
+var (
+	productResource = &resourceKind{typ: "sappgrpc.Product", noun: "product", known: []knownError{
+		{err: ErrNotFound, code: codes.NotFound, reason: "PRODUCT_NOT_FOUND", message: "No product was found with id: %s"},
+		{err: ErrAlreadyExists, duplicateKey: true, code: codes.AlreadyExists, reason: "PRODUCT_ALREADY_EXISTS", message: "Product already exists with id: %s"},
+		{err: ErrConflict, code: codes.Aborted, reason: "ETAG_MISMATCH", message: "Product %s was modified, etag does not match"},
+		{err: ErrNotDeleted, code: codes.FailedPrecondition, reason: "PRODUCT_NOT_DELETED", message: "Product %s is not deleted"},
+	}}
+	categoryResource = &resourceKind{typ: "sappgrpc.Category", noun: "category", known: []knownError{
+		{err: ErrCategoryNotFound, code: codes.NotFound, reason: "CATEGORY_NOT_FOUND", message: "No category was found with path: %s"},
+		{err: ErrCategoryExists, duplicateKey: true, code: codes.AlreadyExists, reason: "CATEGORY_ALREADY_EXISTS", message: "Category already exists with path: %s"},
+	}}
+	variantResource = &resourceKind{typ: "sappgrpc.Variant", noun: "variant", known: []knownError{
+		{err: ErrVariantNotFound, code: codes.NotFound, reason: "VARIANT_NOT_FOUND", message: "No variant was found with id: %s"},
+		{err: ErrSKUExists, duplicateKey: true, code: codes.AlreadyExists, reason: "SKU_ALREADY_EXISTS", message: "The SKU of variant %s is already used by another variant"},
+	}}
+	priceResource = &resourceKind{typ: "sappgrpc.PriceChange", noun: "price change", known: []knownError{
+		{err: ErrPriceNotFound, code: codes.NotFound, reason: "PRICE_CHANGE_NOT_FOUND", message: "No price change was found with id: %s"},
+		{err: ErrPriceApplied, code: codes.FailedPrecondition, reason: "PRICE_CHANGE_APPLIED", message: "Price change %s is already in effect"},
+	}}
+)
+
+// resourceStatus translates an error returned by a store into a gRPC
+// status carrying ErrorInfo and ResourceInfo details. op names the failed
+// operation and name the resource it was applied to.
+func resourceStatus(err error, kind *resourceKind, op, name string) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	for _, k := range kind.known {
+		if errors.Is(err, k.err) || (k.duplicateKey && mongo.IsDuplicateKeyError(err)) {
+			return withDetails(status.New(k.code, fmt.Sprintf(k.message, name)), err, k.reason, op, kind.typ, name)
+		}
+	}
+	code, reason := classify(err)
+	msg := fmt.Sprintf("Failed to %s %s: %v", op, kind.noun, err)
+	return withDetails(status.New(code, msg), err, reason, op, kind.typ, name)
+}
+
+// toStatus is resourceStatus for errors of a ProductStore.
+func toStatus(err error, op, id string) error {
+	return resourceStatus(err, productResource, op, id)
+}
+
+// classify maps the errors every store may return.
+func classify(err error) (codes.Code, string) {
+	switch {
+	case errors.Is(err, ErrInvalidResumeToken):
+		return codes.InvalidArgument, "INVALID_RESUME_TOKEN"
+	case errors.Is(err, ErrResumeTokenExpired):
+		return codes.OutOfRange, "RESUME_TOKEN_EXPIRED"
+	case errors.Is(err, ErrWatchLagged):
+		return codes.Aborted, "WATCH_LAGGED"
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled, "REQUEST_CANCELED"
+	case errors.Is(err, context.DeadlineExceeded) || mongo.IsTimeout(err):
+		return codes.DeadlineExceeded, "STORAGE_TIMEOUT"
+	case isUnavailable(err):
+		return codes.Unavailable, "STORAGE_UNAVAILABLE"
+	default:
+		return codes.Internal, "STORAGE_ERROR"
+	}
+}
+
+func withDetails(errorStatus *status.Status, err error, reason, op, resourceType, name string) error {
+	ds, dErr := errorStatus.WithDetails(
+		&epb.ErrorInfo{
+			Reason:   reason,
+			Domain:   errorDomain,
+			Metadata: map[string]string{"operation": op},
+		},
+		&epb.ResourceInfo{
+			ResourceType: resourceType,
+			ResourceName: name,
+			Description:  err.Error(),
+		},
+	)
+	if dErr != nil {
+		return errorStatus.Err()
+	}
+	return ds.Err()
+}
+
+func isUnavailable(err error) bool {
+	var sel topology.ServerSelectionError
+	return mongo.IsNetworkError(err) ||
+		errors.Is(err, mongo.ErrClientDisconnected) ||
+		errors.As(err, &sel)
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	pb "service/sappgrpc"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	epb "google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestResourceStatus(t *testing.T) {
+	duplicateKey := mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000}}}
+	for _, tt := range []struct {
+		err    error
+		kind   *resourceKind
+		code   codes.Code
+		reason string
+		msg    string
+	}{
+		{ErrNotFound, productResource, codes.NotFound, "PRODUCT_NOT_FOUND", "No product was found with id: x"},
+		{fmt.Errorf("lookup: %w", ErrConflict), productResource, codes.Aborted, "ETAG_MISMATCH", "Product x was modified, etag does not match"},
+		{duplicateKey, productResource, codes.AlreadyExists, "PRODUCT_ALREADY_EXISTS", "Product already exists with id: x"},
+		{ErrNotDeleted, productResource, codes.FailedPrecondition, "PRODUCT_NOT_DELETED", "Product x is not deleted"},
+		{duplicateKey, categoryResource, codes.AlreadyExists, "CATEGORY_ALREADY_EXISTS", "Category already exists with path: x"},
+		{ErrSKUExists, variantResource, codes.AlreadyExists, "SKU_ALREADY_EXISTS", "The SKU of variant x is already used by another variant"},
+		{ErrPriceApplied, priceResource, codes.FailedPrecondition, "PRICE_CHANGE_APPLIED", "Price change x is already in effect"},
+		// Errors of another kind fall through to classify.
+		{ErrNotFound, categoryResource, codes.Internal, "STORAGE_ERROR", "Failed to get category: product not found"},
+		{context.Canceled, variantResource, codes.Canceled, "REQUEST_CANCELED", "Failed to get variant: context canceled"},
+		{context.DeadlineExceeded, productResource, codes.DeadlineExceeded, "STORAGE_TIMEOUT", "Failed to get product: context deadline exceeded"},
+		{ErrResumeTokenExpired, productResource, codes.OutOfRange, "RESUME_TOKEN_EXPIRED", "Failed to get product: resume token is no longer available"},
+	} {
+		st := status.Convert(resourceStatus(tt.err, tt.kind, "get", "x"))
+		if st.Code() != tt.code || st.Message() != tt.msg {
+			t.Errorf("%v as %s: got %v %q, want %v %q", tt.err, tt.kind.noun, st.Code(), st.Message(), tt.code, tt.msg)
+			continue
+		}
+		var info *epb.ErrorInfo
+		var resource *epb.ResourceInfo
+		for _, d := range st.Details() {
+			switch d := d.(type) {
+			case *epb.ErrorInfo:
+				info = d
+			case *epb.ResourceInfo:
+				resource = d
+			}
+		}
+		if info.GetReason() != tt.reason || info.GetMetadata()["operation"] != "get" {
+			t.Errorf("%v as %s: got ErrorInfo %v, want reason %s", tt.err, tt.kind.noun, info, tt.reason)
+		}
+		if resource.GetResourceType() != tt.kind.typ || resource.GetResourceName() != "x" {
+			t.Errorf("%v as %s: got ResourceInfo %v", tt.err, tt.kind.noun, resource)
+		}
+	}
+}
+
+func TestToStatusPassesStatusThrough(t *testing.T) {
+	in := status.Error(codes.Unavailable, "down")
+	if err := toStatus(in, "get", "p"); !errors.Is(err, in) {
+		t.Fatalf("got %v, want %v", err, in)
+	}
+}
+
+func TestGetProductNotFound(t *testing.T) {
+	c := NewProductService(NewMemoryStore())
+	_, err := c.GetProduct(tenantContext("acme"), &pb.ProductID{Value: "missing"})
+	wantStatus(t, err, codes.NotFound, "PRODUCT_NOT_FOUND")
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"sync"
+
+	pb "service/sappgrpc"
+
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// eventLogSize is how many past events the in-memory store keeps for
+	// resuming watchers.
+	eventLogSize = 1024
+	// subscriberBuffer is how far a watcher may fall behind before it is
+	// dropped and has to resume.
+	subscriberBuffer = 256
+)
+
+var (
+	ErrInvalidResumeToken = errors.New("invalid resume token")
+	ErrResumeTokenExpired = errors.New("resume token is no longer available")
+	ErrWatchLagged        = errors.New("watcher fell behind, resume from the last received token")
+)
+
+type subscriber struct {
+	tenant string
+	ch     chan *pb.ProductEvent
+}
+
+// hubEvent is a logged event together with the tenant owning its product.
+type hubEvent struct {
+	tenant string
+	event  *pb.ProductEvent
+}
+
+// eventHub is the in-process pub/sub behind MemoryStore.Watch. It keeps a
+// bounded log of recent events so watchers can resume without gaps.
+// Watchers only see the events of their own tenant.
+type eventHub struct {
+	mu   sync.Mutex
+	seq  uint64
+	log  []hubEvent
+	subs map[*subscriber]struct{}
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{subs: make(map[*subscriber]struct{})}
+}
+
+func (h *eventHub) publish(tenant string, typ pb.ProductEvent_Type, p *pb.Product) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.seq++
+	e := &pb.ProductEvent{
+		Type:        typ,
+		Product:     proto.Clone(p).(*pb.Product),
+		ResumeToken: encodeSeq(h.seq),
+		EventTime:   toTimestamp(now()),
+	}
+	h.log = append(h.log, hubEvent{tenant: tenant, event: e})
+	if len(h.log) > eventLogSize {
+		h.log = h.log[len(h.log)-eventLogSize:]
+	}
+	for s := range h.subs {
+		if s.tenant != tenant {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			close(s.ch)
+			delete(h.subs, s)
+		}
+	}
+}
+
+// subscribe registers a watcher and returns the logged events that follow
+// token. An empty token subscribes to new events only.
+func (h *eventHub) subscribe(tenant, token string) ([]*pb.ProductEvent, *subscriber, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var backlog []*pb.ProductEvent
+	if token != "" {
+		after, err := decodeSeq(token)
+		if err != nil || after > h.seq {
+			return nil, nil, ErrInvalidResumeToken
+		}
+		first := h.seq - uint64(len(h.log)) + 1
+		if after+1 < first {
+			return nil, nil, ErrResumeTokenExpired
+		}
+		for _, e := range h.log[after+1-first:] {
+			if e.tenant == tenant {
+				backlog = append(backlog, e.event)
+			}
+		}
+	}
+	s := &subscriber{tenant: tenant, ch: make(chan *pb.ProductEvent, subscriberBuffer)}
+	h.subs[s] = struct{}{}
+	return backlog, s, nil
+}
+
+func (h *eventHub) unsubscribe(s *subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[s]; ok {
+		close(s.ch)
+		delete(h.subs, s)
+	}
+}
+
+func encodeSeq(seq uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(seq, 10)))
+}
+
+func decodeSeq(token string) (uint64, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(string(b), 10, 64)
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	pb "service/sappgrpc"
+)
+
+func TestEventHubResume(t *testing.T) {
+	h := newEventHub()
+	h.publish("acme", pb.ProductEvent_CREATED, &pb.Product{Id: "1"})
+	h.publish("globex", pb.ProductEvent_CREATED, &pb.Product{Id: "2"})
+	h.publish("acme", pb.ProductEvent_UPDATED, &pb.Product{Id: "1"})
+	h.publish("acme", pb.ProductEvent_CREATED, &pb.Product{Id: "3"})
+
+	backlog, s, err := h.subscribe("acme", encodeSeq(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.unsubscribe(s)
+	if len(backlog) != 2 || backlog[0].ResumeToken != encodeSeq(3) || backlog[1].ResumeToken != encodeSeq(4) {
+		t.Fatalf("got backlog %v", backlog)
+	}
+
+	h.publish("globex", pb.ProductEvent_DELETED, &pb.Product{Id: "2"})
+	h.publish("acme", pb.ProductEvent_DELETED, &pb.Product{Id: "3"})
+	if e := <-s.ch; e.ResumeToken != encodeSeq(6) {
+		t.Fatalf("got event %v, want the one of acme", e)
+	}
+}
+
+func TestEventHubResumeErrors(t *testing.T) {
+	h := newEventHub()
+	for i := 0; i < eventLogSize+2; i++ {
+		h.publish("acme", pb.ProductEvent_CREATED, &pb.Product{Id: "1"})
+	}
+	for _, tt := range []struct {
+		token string
+		want  error
+	}{
+		{"%%%", ErrInvalidResumeToken},
+		{encodeSeq(eventLogSize + 3), ErrInvalidResumeToken},
+		{encodeSeq(1), ErrResumeTokenExpired},
+	} {
+		if _, _, err := h.subscribe("acme", tt.token); !errors.Is(err, tt.want) {
+			t.Errorf("token %q: got %v, want %v", tt.token, err, tt.want)
+		}
+	}
+	// The oldest logged event can still be resumed after.
+	backlog, s, err := h.subscribe("acme", encodeSeq(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.unsubscribe(s)
+	if len(backlog) != eventLogSize {
+		t.Fatalf("got %d backlog events, want %d", len(backlog), eventLogSize)
+	}
+}
+
+func TestEventHubDropsLaggingSubscriber(t *testing.T) {
+	h := newEventHub()
+	_, s, err := h.subscribe("acme", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < subscriberBuffer+1; i++ {
+		h.publish("acme", pb.ProductEvent_CREATED, &pb.Product{Id: "1"})
+	}
+	n := 0
+	for range s.ch {
+		n++
+	}
+	if n != subscriberBuffer {
+		t.Fatalf("got %d events before the channel closed, want %d", n, subscriberBuffer)
+	}
+	// Unsubscribing a dropped subscriber must not close its channel again.
+	h.unsubscribe(s)
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"fmt"
+
+	pb "service/sappgrpc"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+var readOnlyFields = map[protoreflect.Name]bool{
+	"id":          true,
+	"create_time": true,
+	"update_time": true,
+	"etag":        true,
+	"language":    true,
+	"variants":    true,
+	"price":       true,
+	"delete_time": true,
+}
+
+// applyFieldMask copies the fields listed in mask from src to dst.
+// Only top-level fields are supported; the id and server-managed fields
+// can not be changed.
+func applyFieldMask(dst, src *pb.Product, mask *fieldmaskpb.FieldMask) error {
+	if !mask.IsValid(dst) {
+		return fmt.Errorf("invalid update mask: %v", mask.GetPaths())
+	}
+	fields := dst.ProtoReflect().Descriptor().Fields()
+	for _, path := range mask.GetPaths() {
+		fd := fields.ByName(protoreflect.Name(path))
+		if fd == nil {
+			return fmt.Errorf("nested field paths are not supported: %s", path)
+		}
+		if readOnlyFields[fd.Name()] {
+			return fmt.Errorf("field %s can not be updated", path)
+		}
+		if src.ProtoReflect().Has(fd) {
+			dst.ProtoReflect().Set(fd, src.ProtoReflect().Get(fd))
+		} else {
+			dst.ProtoReflect().Clear(fd)
+		}
+	}
+	return nil
+}
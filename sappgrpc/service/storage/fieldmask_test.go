@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"testing"
+
+	pb "service/sappgrpc"
+
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func TestApplyFieldMask(t *testing.T) {
+	dst := &pb.Product{Id: "1", Name: "Kettle", Description: "Boils water", Tags: []string{"steel"}}
+	src := &pb.Product{Id: "2", Name: "Teapot"}
+	if err := applyFieldMask(dst, src, &fieldmaskpb.FieldMask{Paths: []string{"name", "tags"}}); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Id != "1" || dst.Name != "Teapot" || dst.Description != "Boils water" || len(dst.Tags) != 0 {
+		t.Fatalf("got %v", dst)
+	}
+}
+
+func TestApplyFieldMaskErrors(t *testing.T) {
+	for _, paths := range [][]string{
+		{"id"},
+		{"etag"},
+		{"delete_time"},
+		{"no_such_field"},
+		{"create_time.seconds"},
+	} {
+		dst := &pb.Product{Id: "1", Name: "Kettle"}
+		if err := applyFieldMask(dst, &pb.Product{}, &fieldmaskpb.FieldMask{Paths: paths}); err == nil {
+			t.Errorf("paths %v were accepted", paths)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	pb "service/sappgrpc"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	idempotencyKeyHeader      = "idempotency-key"
+	DefaultIdempotencyTTL     = 24 * time.Hour
+	IdempotencyCollectionName = "idempotency_keys"
+)
+
+// IdempotencyRecord remembers which product an AddProduct call made with
+// an idempotency key created. A record is pending until the product is
+// stored, so a replay racing the first call does not get an id that may
+// never exist.
+type IdempotencyRecord struct {
+	Key         string    `bson:"key"`
+	RequestHash string    `bson:"request_hash"`
+	ProductID   string    `bson:"product_id"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+	Pending     bool      `bson:"pending,omitempty"`
+}
+
+// IdempotencyStore keeps idempotency records until they expire.
+type IdempotencyStore interface {
+	// Claim stores rec unless an unexpired record with the same key exists.
+	// In that case the existing record is returned and claimed is false.
+	Claim(ctx context.Context, rec IdempotencyRecord) (existing IdempotencyRecord, claimed bool, err error)
+	// Complete marks a claimed key as done once the product is stored.
+	Complete(ctx context.Context, key string) error
+	// Release forgets a claimed key, so a failed call can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// idempotencyKey returns the idempotency-key request header, if any.
+func idempotencyKey(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if v := md.Get(idempotencyKeyHeader); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// scopedIdempotencyKey prefixes key with the tenant of ctx, so tenants
+// can not replay each other's requests.
+func scopedIdempotencyKey(ctx context.Context, key string) string {
+	return TenantFromContext(ctx) + "/" + key
+}
+
+// requestHash fingerprints the client supplied part of an AddProduct
+// request, so replays with a different payload can be told apart. The id
+// is part of it only if client supplied ids are accepted.
+func requestHash(req *pb.Product, withID bool) string {
+	p := proto.Clone(req).(*pb.Product)
+	if !withID {
+		p.Id = ""
+	}
+	p.CreateTime = nil
+	p.UpdateTime = nil
+	p.Etag = ""
+	p.Language = ""
+	p.Variants = nil
+	p.Price = nil
+	p.DeleteTime = nil
+	b, _ := proto.MarshalOptions{Deterministic: true}.Marshal(p)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
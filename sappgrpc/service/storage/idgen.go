@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/gofrs/uuid"
+	"github.com/oklog/ulid/v2"
+)
+
+// IDGenerator makes the ids of new products.
+type IDGenerator interface {
+	NewID() (string, error)
+	// Parse checks that a client supplied id has the format of the
+	// generated ids and returns it in canonical form.
+	Parse(id string) (string, error)
+}
+
+// NewIDGenerator returns the generator called name: uuidv4, uuidv7 or
+// ulid. UUIDv7 and ULID ids sort in creation order, which keeps index
+// inserts local and lets ListProducts page in creation order.
+func NewIDGenerator(name string) (IDGenerator, error) {
+	switch name {
+	case "uuidv4":
+		return UUIDv4{}, nil
+	case "uuidv7":
+		return UUIDv7{}, nil
+	case "ulid":
+		return ULID{}, nil
+	default:
+		return nil, fmt.Errorf("unknown id generator %q", name)
+	}
+}
+
+// UUIDv4 generates random UUIDs.
+type UUIDv4 struct{}
+
+func (UUIDv4) NewID() (string, error) {
+	id, err := uuid.NewV4()
+	return id.String(), err
+}
+
+func (UUIDv4) Parse(id string) (string, error) {
+	return parseUUID(id, uuid.V4)
+}
+
+// UUIDv7 generates UUIDs that start with a millisecond timestamp. Ids made
+// by one process within the same millisecond still increase.
+type UUIDv7 struct{}
+
+func (UUIDv7) NewID() (string, error) {
+	id, err := uuid.NewV7()
+	return id.String(), err
+}
+
+func (UUIDv7) Parse(id string) (string, error) {
+	return parseUUID(id, uuid.V7)
+}
+
+func parseUUID(s string, version byte) (string, error) {
+	id, err := uuid.FromString(s)
+	if err != nil {
+		return "", err
+	}
+	if id.Version() != version {
+		return "", fmt.Errorf("not a version %d UUID", version)
+	}
+	return id.String(), nil
+}
+
+// ULID generates ULIDs, monotonic within the same millisecond.
+type ULID struct{}
+
+func (ULID) NewID() (string, error) {
+	return ulid.Make().String(), nil
+}
+
+func (ULID) Parse(s string) (string, error) {
+	id, err := ulid.ParseStrict(s)
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	pb "service/sappgrpc"
+
+	"github.com/gofrs/uuid"
+	"github.com/oklog/ulid/v2"
+)
+
+func TestIDGenerators(t *testing.T) {
+	v4, v7 := uuid.Must(uuid.NewV4()).String(), uuid.Must(uuid.NewV7()).String()
+	lid := ulid.Make().String()
+	for _, tt := range []struct {
+		name    string
+		valid   []string
+		invalid []string
+	}{
+		{"uuidv4", []string{v4, "{" + v4 + "}"}, []string{v7, lid, "", "kettle"}},
+		{"uuidv7", []string{v7}, []string{v4, lid, "", "kettle"}},
+		// Lower case is accepted, but ParseStrict rejects characters
+		// outside of the Crockford alphabet and overflowing timestamps.
+		{"ulid", []string{lid}, []string{v4, "01ARZ3NDEKTSV4RRFFQ69G5FAU", "81ARZ3NDEKTSV4RRFFQ69G5FAV", ""}},
+	} {
+		gen, err := NewIDGenerator(tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, err := gen.NewID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := gen.Parse(id); err != nil || got != id {
+			t.Errorf("%s: got %q, %v parsing generated id %q", tt.name, got, err, id)
+		}
+		for _, s := range tt.valid {
+			if _, err := gen.Parse(s); err != nil {
+				t.Errorf("%s: got %v parsing %q", tt.name, err, s)
+			}
+		}
+		for _, s := range tt.invalid {
+			if got, err := gen.Parse(s); err == nil {
+				t.Errorf("%s: parsed %q as %q", tt.name, s, got)
+			}
+		}
+	}
+	if _, err := NewIDGenerator("snowflake"); err == nil {
+		t.Fatal("got no error for an unknown generator")
+	}
+}
+
+func TestParseCanonicalizes(t *testing.T) {
+	id := uuid.Must(uuid.NewV4())
+	if got, err := (UUIDv4{}).Parse("urn:uuid:" + id.String()); err != nil || got != id.String() {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	lid := ulid.Make().String()
+	if got, err := (ULID{}).Parse(strings.ToLower(lid)); err != nil || got != lid {
+		t.Fatalf("got %q, %v for a lower case ULID", got, err)
+	}
+}
+
+func TestImportProductsClientIDs(t *testing.T) {
+	m := NewMemoryStore()
+	c := NewProductService(m, WithIDGenerator(UUIDv7{}), WithClientIDs())
+	ctx := tenantContext("acme")
+	v7 := uuid.Must(uuid.NewV7()).String()
+	s := &importStream{ctx: ctx, rows: []*pb.Product{
+		{Id: v7, Name: "Kettle"},
+		{Id: uuid.Must(uuid.NewV4()).String(), Name: "Toaster"},
+		{Name: "Lamp"},
+		{Id: v7, Name: "Kettle again"},
+	}}
+	if err := c.ImportProducts(s); err != nil {
+		t.Fatal(err)
+	}
+	if s.summary.Imported != 2 || s.summary.Failed != 2 {
+		t.Fatalf("got summary %v", s.summary)
+	}
+	for i, row := range []int32{2, 4} {
+		if s.summary.Errors[i].Row != row {
+			t.Fatalf("error %d is for row %d, want %d", i, s.summary.Errors[i].Row, row)
+		}
+	}
+	p, err := m.Get(ctx, v7)
+	if err != nil {
+		t.Fatalf("got %v for the client id", err)
+	}
+	if p.Name != "Kettle" {
+		t.Fatalf("got %q for the client id", p.Name)
+	}
+
+	// Without WithClientIDs the ids are generated.
+	c = NewProductService(NewMemoryStore(), WithIDGenerator(UUIDv7{}))
+	s = &importStream{ctx: ctx, rows: []*pb.Product{{Id: "kettle", Name: "Kettle"}}}
+	if err := c.ImportProducts(s); err != nil {
+		t.Fatal(err)
+	}
+	if s.summary.Imported != 1 {
+		t.Fatalf("got summary %v", s.summary)
+	}
+}
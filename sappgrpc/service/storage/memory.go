@@ -0,0 +1,635 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	pb "service/sappgrpc"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// MemoryStore keeps products in process memory. It is meant for local runs
+// and tests where no MongoDB is available.
+type MemoryStore struct {
+	mu sync.RWMutex
+	// products are keyed by tenant and id, ids are only unique within
+	// a tenant.
+	products map[ProductRef]*pb.Product
+	events   *eventHub
+	// indexes maps tenants to the search index of their products.
+	indexes map[string]*invertedIndex
+
+	keys      map[string]IdempotencyRecord
+	lastSweep time.Time
+
+	// categories maps tenants to their categories by path.
+	categories map[string]map[string]*pb.Category
+
+	variants map[string]memoryVariant
+	// skus maps tenants to their variant ids by SKU.
+	skus map[string]map[string]string
+
+	prices map[string]memoryPrice
+
+	// audit maps tenants to their audit events in the order they were
+	// appended.
+	audit map[string][]*pb.AuditEvent
+}
+
+type memoryPrice struct {
+	tenant string
+	change *pb.PriceChange
+}
+
+type memoryVariant struct {
+	tenant  string
+	variant *pb.Variant
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		products: make(map[ProductRef]*pb.Product),
+		events:   newEventHub(),
+		indexes:  make(map[string]*invertedIndex),
+		keys:     make(map[string]IdempotencyRecord),
+
+		categories: make(map[string]map[string]*pb.Category),
+
+		variants: make(map[string]memoryVariant),
+		skus:     make(map[string]map[string]string),
+
+		prices: make(map[string]memoryPrice),
+
+		audit: make(map[string][]*pb.AuditEvent),
+	}
+}
+
+func (m *MemoryStore) Add(ctx context.Context, p *pb.Product) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tenant := TenantFromContext(ctx)
+	ref := ProductRef{Tenant: tenant, ID: p.Id}
+	if _, ok := m.products[ref]; ok {
+		return ErrAlreadyExists
+	}
+	p.CreateTime = toTimestamp(now())
+	p.UpdateTime = p.CreateTime
+	p.Etag = formatETag(1)
+	m.products[ref] = proto.Clone(p).(*pb.Product)
+	m.index(tenant).add(p)
+	m.events.publish(tenant, pb.ProductEvent_CREATED, p)
+	return nil
+}
+
+// index returns the search index of tenant. m.mu must be held for
+// writing.
+func (m *MemoryStore) index(tenant string) *invertedIndex {
+	ix, ok := m.indexes[tenant]
+	if !ok {
+		ix = newInvertedIndex()
+		m.indexes[tenant] = ix
+	}
+	return ix
+}
+
+func (m *MemoryStore) AddMany(ctx context.Context, ps []*pb.Product) ([]error, error) {
+	errs := make([]error, len(ps))
+	for i, p := range ps {
+		errs[i] = m.Add(ctx, p)
+	}
+	return errs, nil
+}
+
+func (m *MemoryStore) Get(ctx context.Context, id string) (*pb.Product, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	p, err := m.lookup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Clone(p).(*pb.Product), nil
+}
+
+func (m *MemoryStore) GetMany(ctx context.Context, ids []string) ([]*pb.Product, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result := make([]*pb.Product, 0, len(ids))
+	for _, id := range ids {
+		if p, err := m.lookup(ctx, id); err == nil {
+			result = append(result, proto.Clone(p).(*pb.Product))
+		}
+	}
+	return result, nil
+}
+
+// lookup returns the product with id if it belongs to the tenant of ctx
+// and is not deleted.
+// m.mu must be held.
+func (m *MemoryStore) lookup(ctx context.Context, id string) (*pb.Product, error) {
+	p, ok := m.products[ProductRef{Tenant: TenantFromContext(ctx), ID: id}]
+	if !ok || p.DeleteTime != nil {
+		return nil, ErrNotFound
+	}
+	return p, nil
+}
+
+func (m *MemoryStore) Update(ctx context.Context, p *pb.Product) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	old, err := m.lookup(ctx, p.Id)
+	if err != nil {
+		return err
+	}
+	if p.Etag != "" && p.Etag != old.Etag {
+		return ErrConflict
+	}
+	version, _ := parseETag(old.Etag)
+	p.Price = old.Price
+	p.DeleteTime = nil
+	p.CreateTime = old.CreateTime
+	p.UpdateTime = toTimestamp(now())
+	p.Etag = formatETag(version + 1)
+	tenant := TenantFromContext(ctx)
+	m.products[ProductRef{Tenant: tenant, ID: p.Id}] = proto.Clone(p).(*pb.Product)
+	m.index(tenant).remove(old)
+	m.index(tenant).add(p)
+	m.events.publish(tenant, pb.ProductEvent_UPDATED, p)
+	return nil
+}
+
+func (m *MemoryStore) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	old, err := m.lookup(ctx, id)
+	if err != nil {
+		return err
+	}
+	p := proto.Clone(old).(*pb.Product)
+	version, _ := parseETag(old.Etag)
+	p.UpdateTime = toTimestamp(now())
+	p.DeleteTime = p.UpdateTime
+	p.Etag = formatETag(version + 1)
+	tenant := TenantFromContext(ctx)
+	m.products[ProductRef{Tenant: tenant, ID: id}] = p
+	m.index(tenant).remove(old)
+	m.events.publish(tenant, pb.ProductEvent_DELETED, p)
+	return nil
+}
+
+func (m *MemoryStore) Restore(ctx context.Context, id string) (*pb.Product, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tenant := TenantFromContext(ctx)
+	ref := ProductRef{Tenant: tenant, ID: id}
+	old, ok := m.products[ref]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if old.DeleteTime == nil {
+		return nil, ErrNotDeleted
+	}
+	p := proto.Clone(old).(*pb.Product)
+	version, _ := parseETag(old.Etag)
+	p.UpdateTime = toTimestamp(now())
+	p.DeleteTime = nil
+	p.Etag = formatETag(version + 1)
+	m.products[ref] = p
+	m.index(tenant).add(p)
+	m.events.publish(tenant, pb.ProductEvent_UPDATED, p)
+	return proto.Clone(p).(*pb.Product), nil
+}
+
+func (m *MemoryStore) Purge(ctx context.Context, before time.Time) ([]PurgedProduct, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var purged []PurgedProduct
+	for ref, p := range m.products {
+		if p.DeleteTime != nil && fromTimestamp(p.DeleteTime).Before(before) {
+			purged = append(purged, PurgedProduct{Tenant: ref.Tenant, Product: p})
+			delete(m.products, ref)
+		}
+	}
+	return purged, nil
+}
+
+func (m *MemoryStore) List(ctx context.Context, filter ProductFilter, after string, limit int) ([]*pb.Product, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	tenant := TenantFromContext(ctx)
+	ids := make([]string, 0, len(m.products))
+	for ref, p := range m.products {
+		if ref.Tenant == tenant && ref.ID > after && filter.match(p) {
+			ids = append(ids, ref.ID)
+		}
+	}
+	sort.Strings(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	result := make([]*pb.Product, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, proto.Clone(m.products[ProductRef{Tenant: tenant, ID: id}]).(*pb.Product))
+	}
+	return result, nil
+}
+
+func (m *MemoryStore) Facets(ctx context.Context, filter ProductFilter) ([]*pb.FacetCount, []*pb.FacetCount, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	tenant := TenantFromContext(ctx)
+	counter := newFacetCounter()
+	for ref, p := range m.products {
+		if ref.Tenant == tenant && filter.match(p) {
+			counter.add(p)
+		}
+	}
+	categories, tags := counter.result()
+	return categories, tags, nil
+}
+
+func (m *MemoryStore) Search(ctx context.Context, query string, prefix bool, filter ProductFilter, limit int) ([]*pb.SearchResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	tenant := TenantFromContext(ctx)
+	var scores map[string]float64
+	if ix, ok := m.indexes[tenant]; ok {
+		scores = ix.search(tokenize(query), prefix)
+	}
+	results := make([]*pb.SearchResult, 0, len(scores))
+	for id, score := range scores {
+		p := m.products[ProductRef{Tenant: tenant, ID: id}]
+		if !filter.match(p) {
+			continue
+		}
+		results = append(results, &pb.SearchResult{
+			Product: proto.Clone(p).(*pb.Product),
+			Score:   score,
+		})
+	}
+	sortResults(results)
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func (m *MemoryStore) Watch(ctx context.Context, resumeToken string, fn func(*pb.ProductEvent) error) error {
+	backlog, sub, err := m.events.subscribe(TenantFromContext(ctx), resumeToken)
+	if err != nil {
+		return err
+	}
+	defer m.events.unsubscribe(sub)
+	for _, e := range backlog {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case e, ok := <-sub.ch:
+			if !ok {
+				return ErrWatchLagged
+			}
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (m *MemoryStore) Claim(ctx context.Context, rec IdempotencyRecord) (IdempotencyRecord, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ts := time.Now()
+	if ts.Sub(m.lastSweep) > time.Minute {
+		for k, r := range m.keys {
+			if r.ExpiresAt.Before(ts) {
+				delete(m.keys, k)
+			}
+		}
+		m.lastSweep = ts
+	}
+	if r, ok := m.keys[rec.Key]; ok && r.ExpiresAt.After(ts) {
+		return r, false, nil
+	}
+	m.keys[rec.Key] = rec
+	return rec, true, nil
+}
+
+func (m *MemoryStore) Complete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if r, ok := m.keys[key]; ok {
+		r.Pending = false
+		m.keys[key] = r
+	}
+	return nil
+}
+
+func (m *MemoryStore) Release(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.keys, key)
+	return nil
+}
+
+func (m *MemoryStore) AddCategory(ctx context.Context, c *pb.Category) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tenant := TenantFromContext(ctx)
+	cats := m.categories[tenant]
+	if cats == nil {
+		cats = make(map[string]*pb.Category)
+		m.categories[tenant] = cats
+	}
+	if _, ok := cats[c.Path]; ok {
+		return ErrCategoryExists
+	}
+	c.CreateTime = toTimestamp(now())
+	cats[c.Path] = proto.Clone(c).(*pb.Category)
+	return nil
+}
+
+func (m *MemoryStore) GetCategory(ctx context.Context, path string) (*pb.Category, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	c, ok := m.categories[TenantFromContext(ctx)][path]
+	if !ok {
+		return nil, ErrCategoryNotFound
+	}
+	return proto.Clone(c).(*pb.Category), nil
+}
+
+func (m *MemoryStore) UpdateCategory(ctx context.Context, c *pb.Category) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	old, ok := m.categories[TenantFromContext(ctx)][c.Path]
+	if !ok {
+		return ErrCategoryNotFound
+	}
+	old.DisplayName = c.DisplayName
+	c.CreateTime = old.CreateTime
+	return nil
+}
+
+func (m *MemoryStore) DeleteCategory(ctx context.Context, path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cats := m.categories[TenantFromContext(ctx)]
+	if _, ok := cats[path]; !ok {
+		return ErrCategoryNotFound
+	}
+	delete(cats, path)
+	return nil
+}
+
+func (m *MemoryStore) ListCategories(ctx context.Context, parent string, recursive bool) ([]*pb.Category, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var result []*pb.Category
+	for path, c := range m.categories[TenantFromContext(ctx)] {
+		if path == parent {
+			continue
+		}
+		if recursive && (parent == "" || inCategory(path, parent)) || parentCategory(path) == parent {
+			result = append(result, proto.Clone(c).(*pb.Category))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+	return result, nil
+}
+
+func (m *MemoryStore) AddVariant(ctx context.Context, v *pb.Variant) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tenant := TenantFromContext(ctx)
+	skus := m.skus[tenant]
+	if skus == nil {
+		skus = make(map[string]string)
+		m.skus[tenant] = skus
+	}
+	if _, ok := skus[v.Sku]; ok {
+		return ErrSKUExists
+	}
+	v.CreateTime = toTimestamp(now())
+	v.UpdateTime = v.CreateTime
+	m.variants[v.Id] = memoryVariant{tenant: tenant, variant: proto.Clone(v).(*pb.Variant)}
+	skus[v.Sku] = v.Id
+	return nil
+}
+
+// lookupVariant returns the variant with id if it belongs to the tenant of
+// ctx. m.mu must be held.
+func (m *MemoryStore) lookupVariant(ctx context.Context, id string) (*pb.Variant, error) {
+	mv, ok := m.variants[id]
+	if !ok || mv.tenant != TenantFromContext(ctx) {
+		return nil, ErrVariantNotFound
+	}
+	return mv.variant, nil
+}
+
+func (m *MemoryStore) GetVariant(ctx context.Context, id string) (*pb.Variant, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	v, err := m.lookupVariant(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Clone(v).(*pb.Variant), nil
+}
+
+func (m *MemoryStore) UpdateVariant(ctx context.Context, v *pb.Variant) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	old, err := m.lookupVariant(ctx, v.Id)
+	if err != nil {
+		return err
+	}
+	skus := m.skus[TenantFromContext(ctx)]
+	if id, ok := skus[v.Sku]; ok && id != v.Id {
+		return ErrSKUExists
+	}
+	delete(skus, old.Sku)
+	skus[v.Sku] = v.Id
+	v.ProductId = old.ProductId
+	v.CreateTime = old.CreateTime
+	v.UpdateTime = toTimestamp(now())
+	m.variants[v.Id] = memoryVariant{tenant: TenantFromContext(ctx), variant: proto.Clone(v).(*pb.Variant)}
+	return nil
+}
+
+func (m *MemoryStore) DeleteVariant(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, err := m.lookupVariant(ctx, id)
+	if err != nil {
+		return err
+	}
+	delete(m.skus[TenantFromContext(ctx)], v.Sku)
+	delete(m.variants, id)
+	return nil
+}
+
+func (m *MemoryStore) ListVariants(ctx context.Context, productID string) ([]*pb.Variant, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	tenant := TenantFromContext(ctx)
+	var result []*pb.Variant
+	for _, mv := range m.variants {
+		if mv.tenant == tenant && mv.variant.ProductId == productID {
+			result = append(result, proto.Clone(mv.variant).(*pb.Variant))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Sku < result[j].Sku })
+	return result, nil
+}
+
+func (m *MemoryStore) DeleteProductVariants(ctx context.Context, productID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tenant := TenantFromContext(ctx)
+	for id, mv := range m.variants {
+		if mv.tenant == tenant && mv.variant.ProductId == productID {
+			delete(m.skus[tenant], mv.variant.Sku)
+			delete(m.variants, id)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) AddPrice(ctx context.Context, pc *pb.PriceChange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prices[pc.Id] = memoryPrice{tenant: TenantFromContext(ctx), change: proto.Clone(pc).(*pb.PriceChange)}
+	return nil
+}
+
+func (m *MemoryStore) DeletePrice(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mp, ok := m.prices[id]
+	if !ok || mp.tenant != TenantFromContext(ctx) {
+		return ErrPriceNotFound
+	}
+	if mp.change.Applied {
+		return ErrPriceApplied
+	}
+	delete(m.prices, id)
+	return nil
+}
+
+// history returns the sorted price history of a product. m.mu must be held.
+func (m *MemoryStore) history(tenant, productID string) []*pb.PriceChange {
+	var result []*pb.PriceChange
+	for _, mp := range m.prices {
+		if mp.tenant == tenant && mp.change.ProductId == productID {
+			result = append(result, mp.change)
+		}
+	}
+	sortPrices(result)
+	return result
+}
+
+func (m *MemoryStore) ListPrices(ctx context.Context, productID string) ([]*pb.PriceChange, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	history := m.history(TenantFromContext(ctx), productID)
+	for i, pc := range history {
+		history[i] = proto.Clone(pc).(*pb.PriceChange)
+	}
+	return history, nil
+}
+
+func (m *MemoryStore) PriceAt(ctx context.Context, productID string, t time.Time) (*pb.PriceChange, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	pc := currentPrice(m.history(TenantFromContext(ctx), productID), t)
+	if pc == nil {
+		return nil, ErrNoPrice
+	}
+	return proto.Clone(pc).(*pb.PriceChange), nil
+}
+
+func (m *MemoryStore) ApplyPrices(ctx context.Context, now time.Time) ([]ProductRef, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	due := make(map[ProductRef][]*pb.PriceChange)
+	for _, mp := range m.prices {
+		if !mp.change.Applied && !fromTimestamp(mp.change.EffectiveTime).After(now) {
+			ref := ProductRef{Tenant: mp.tenant, ID: mp.change.ProductId}
+			due[ref] = append(due[ref], mp.change)
+		}
+	}
+	var refs []ProductRef
+	for ref, changes := range due {
+		old, ok := m.products[ref]
+		if !ok || old.DeleteTime != nil {
+			continue
+		}
+		for _, pc := range changes {
+			pc.Applied = true
+		}
+		p := proto.Clone(old).(*pb.Product)
+		if pc := currentPrice(m.history(ref.Tenant, ref.ID), now); pc != nil {
+			p.Price = proto.Clone(pc.Price).(*pb.Money)
+		}
+		version, _ := parseETag(old.Etag)
+		p.UpdateTime = toTimestamp(now)
+		p.Etag = formatETag(version + 1)
+		m.products[ref] = p
+		m.events.publish(ref.Tenant, pb.ProductEvent_UPDATED, p)
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+func (m *MemoryStore) DeleteProductPrices(ctx context.Context, productID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tenant := TenantFromContext(ctx)
+	for id, mp := range m.prices {
+		if mp.tenant == tenant && mp.change.ProductId == productID {
+			delete(m.prices, id)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) AppendAudit(ctx context.Context, e *pb.AuditEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tenant := TenantFromContext(ctx)
+	m.audit[tenant] = append(m.audit[tenant], proto.Clone(e).(*pb.AuditEvent))
+	return nil
+}
+
+// ListAudit sorts by id, since concurrent writes may append events out
+// of id order.
+func (m *MemoryStore) ListAudit(ctx context.Context, productID, after string, limit int) ([]*pb.AuditEvent, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var result []*pb.AuditEvent
+	for _, e := range m.audit[TenantFromContext(ctx)] {
+		if e.ProductId == productID && e.Id > after {
+			result = append(result, proto.Clone(e).(*pb.AuditEvent))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Id < result[j].Id })
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (m *MemoryStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (m *MemoryStore) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,911 @@
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"regexp"
+	"time"
+
+	pb "service/sappgrpc"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"google.golang.org/protobuf/proto"
+)
+
+type MongoStore struct {
+	DB         *mongo.Client
+	Coll       *mongo.Collection
+	Keys       *mongo.Collection
+	Categories *mongo.Collection
+	Variants   *mongo.Collection
+	Prices     *mongo.Collection
+	Audit      *mongo.Collection
+}
+
+type MongoOptions struct {
+	URI        string
+	Database   string
+	Collection string
+	// Timeout bounds connecting and server selection.
+	Timeout time.Duration
+}
+
+func NewMongoStore(o MongoOptions) (*MongoStore, error) {
+	if o.URI == "" {
+		return nil, errors.New("empty MongoDB URI")
+	}
+	opts := options.Client().ApplyURI(o.URI)
+	if o.Timeout > 0 {
+		opts.SetConnectTimeout(o.Timeout).SetServerSelectionTimeout(o.Timeout)
+	}
+	client, err := mongo.Connect(opts)
+	if err != nil {
+		return nil, err
+	}
+	db := client.Database(o.Database)
+	return &MongoStore{
+		DB:         client,
+		Coll:       db.Collection(o.Collection),
+		Keys:       db.Collection(IdempotencyCollectionName),
+		Categories: db.Collection(CategoryCollectionName),
+		Variants:   db.Collection(VariantCollectionName),
+		Prices:     db.Collection(PriceCollectionName),
+		Audit:      db.Collection(AuditCollectionName),
+	}, nil
+}
+
+// Add inserts p and fills in its timestamps.
+func (m *MongoStore) Add(ctx context.Context, p *pb.Product) error {
+	doc := newProductDocument(p)
+	doc.Tenant = TenantFromContext(ctx)
+	doc.CreatedAt = now()
+	doc.UpdatedAt = doc.CreatedAt
+	doc.Version = 1
+	if _, err := m.Coll.InsertOne(ctx, doc); err != nil {
+		return err
+	}
+	p.CreateTime = toTimestamp(doc.CreatedAt)
+	p.UpdateTime = toTimestamp(doc.UpdatedAt)
+	p.Etag = formatETag(doc.Version)
+	return nil
+}
+
+func (m *MongoStore) AddMany(ctx context.Context, ps []*pb.Product) ([]error, error) {
+	ts := now()
+	tenant := TenantFromContext(ctx)
+	docs := make([]productDocument, len(ps))
+	for i, p := range ps {
+		docs[i] = newProductDocument(p)
+		docs[i].Tenant = tenant
+		docs[i].CreatedAt = ts
+		docs[i].UpdatedAt = ts
+		docs[i].Version = 1
+	}
+	errs := make([]error, len(ps))
+	_, err := m.Coll.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	var bwe mongo.BulkWriteException
+	if errors.As(err, &bwe) && bwe.WriteConcernError == nil {
+		for _, we := range bwe.WriteErrors {
+			errs[we.Index] = we.WriteError
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	for i, p := range ps {
+		if errs[i] == nil {
+			p.CreateTime = toTimestamp(ts)
+			p.UpdateTime = p.CreateTime
+			p.Etag = formatETag(1)
+		}
+	}
+	return errs, nil
+}
+
+func (m *MongoStore) Get(ctx context.Context, id string) (*pb.Product, error) {
+	var result productDocument
+	err := m.Coll.FindOne(ctx, tenantFilter(ctx, bson.E{Key: "id", Value: id}, notDeleted)).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result.toProto(), nil
+}
+
+// GetMany reads all products with a single $in query.
+func (m *MongoStore) GetMany(ctx context.Context, ids []string) ([]*pb.Product, error) {
+	filter := tenantFilter(ctx, bson.E{Key: "id", Value: bson.D{{Key: "$in", Value: ids}}}, notDeleted)
+	cur, err := m.Coll.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var docs []productDocument
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	result := make([]*pb.Product, 0, len(docs))
+	for _, d := range docs {
+		result = append(result, d.toProto())
+	}
+	return result, nil
+}
+
+// notDeleted matches the products that are not soft deleted.
+var notDeleted = bson.E{Key: "deleted_at", Value: nil}
+
+// tenantFilter restricts a query to the products of the tenant of ctx.
+func tenantFilter(ctx context.Context, filter ...bson.E) bson.D {
+	return append(bson.D{{Key: "tenant", Value: TenantFromContext(ctx)}}, filter...)
+}
+
+// productFilter turns filter into a query on the products of the tenant
+// of ctx.
+func productFilter(ctx context.Context, filter ProductFilter, extra ...bson.E) bson.D {
+	q := tenantFilter(ctx)
+	if !filter.ShowDeleted {
+		q = append(q, notDeleted)
+	}
+	if filter.Category != "" {
+		re := bson.Regex{Pattern: "^" + regexp.QuoteMeta(filter.Category) + "(/|$)"}
+		q = append(q, bson.E{Key: "category", Value: re})
+	}
+	if len(filter.Tags) > 0 {
+		q = append(q, bson.E{Key: "tags", Value: bson.D{{Key: "$all", Value: filter.Tags}}})
+	}
+	return append(q, extra...)
+}
+
+// missing explains why a write filtered by tenantFilter matched nothing.
+// It returns nil if the product exists for the tenant of ctx and is not
+// deleted.
+func (m *MongoStore) missing(ctx context.Context, id string) error {
+	var result struct {
+		DeletedAt time.Time `bson:"deleted_at"`
+	}
+	opts := options.FindOne().SetProjection(bson.D{{Key: "deleted_at", Value: 1}})
+	err := m.Coll.FindOne(ctx, tenantFilter(ctx, bson.E{Key: "id", Value: id}), opts).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !result.DeletedAt.IsZero() {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Update keeps the creation time of the stored product. The etag check and
+// the write are a single FindOneAndUpdate, so concurrent updates can not
+// both succeed.
+func (m *MongoStore) Update(ctx context.Context, p *pb.Product) error {
+	filter := tenantFilter(ctx, bson.E{Key: "id", Value: p.Id}, notDeleted)
+	if p.Etag != "" {
+		version, err := parseETag(p.Etag)
+		if err != nil {
+			return ErrConflict
+		}
+		filter = append(filter, bson.E{Key: "version", Value: version})
+	}
+	ts := now()
+	set := bson.D{
+		{Key: "name", Value: p.Name},
+		{Key: "description", Value: p.Description},
+		{Key: "translations", Value: newTranslationDocuments(p.Translations)},
+		{Key: "category", Value: p.Category},
+		{Key: "tags", Value: p.Tags},
+		{Key: "schema_version", Value: schemaVersion},
+		{Key: "updated_at", Value: ts},
+	}
+	update := bson.D{
+		{Key: "$set", Value: set},
+		// Documents written before schema versioning have no created_at.
+		{Key: "$min", Value: bson.D{{Key: "created_at", Value: ts}}},
+		{Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var result productDocument
+	err := m.Coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		if err := m.missing(ctx, p.Id); err != nil {
+			return err
+		}
+		if p.Etag == "" {
+			return ErrNotFound
+		}
+		return ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	proto.Reset(p)
+	proto.Merge(p, result.toProto())
+	return nil
+}
+
+// Delete only sets deleted_at, the document is removed by Purge.
+func (m *MongoStore) Delete(ctx context.Context, id string) error {
+	ts := now()
+	update := bson.D{
+		{Key: "$set", Value: bson.D{{Key: "deleted_at", Value: ts}, {Key: "updated_at", Value: ts}}},
+		{Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}},
+	}
+	res, err := m.Coll.UpdateOne(ctx, tenantFilter(ctx, bson.E{Key: "id", Value: id}, notDeleted), update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		if err := m.missing(ctx, id); err != nil {
+			return err
+		}
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m *MongoStore) Restore(ctx context.Context, id string) (*pb.Product, error) {
+	filter := tenantFilter(ctx,
+		bson.E{Key: "id", Value: id},
+		bson.E{Key: "deleted_at", Value: bson.D{{Key: "$ne", Value: nil}}},
+	)
+	update := bson.D{
+		{Key: "$unset", Value: bson.D{{Key: "deleted_at", Value: ""}}},
+		{Key: "$set", Value: bson.D{{Key: "updated_at", Value: now()}}},
+		{Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var result productDocument
+	err := m.Coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		if err := m.missing(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrNotDeleted
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result.toProto(), nil
+}
+
+// Purge deletes each document only if its version is unchanged, so a
+// product restored in the meantime is kept.
+func (m *MongoStore) Purge(ctx context.Context, before time.Time) ([]PurgedProduct, error) {
+	cur, err := m.Coll.Find(ctx, bson.D{{Key: "deleted_at", Value: bson.D{{Key: "$lt", Value: before}}}})
+	if err != nil {
+		return nil, err
+	}
+	var docs []productDocument
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	var purged []PurgedProduct
+	for _, d := range docs {
+		filter := bson.D{{Key: "tenant", Value: d.Tenant}, {Key: "id", Value: d.ID}, {Key: "version", Value: d.Version}}
+		res, err := m.Coll.DeleteOne(ctx, filter)
+		if err != nil {
+			return purged, err
+		}
+		if res.DeletedCount == 1 {
+			purged = append(purged, PurgedProduct{Tenant: d.Tenant, Product: d.toProto()})
+		}
+	}
+	return purged, nil
+}
+
+func (m *MongoStore) List(ctx context.Context, pf ProductFilter, after string, limit int) ([]*pb.Product, error) {
+	filter := productFilter(ctx, pf)
+	if after != "" {
+		filter = append(filter, bson.E{Key: "id", Value: bson.D{{Key: "$gt", Value: after}}})
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "id", Value: 1}}).SetLimit(int64(limit))
+	cur, err := m.Coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var docs []productDocument
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	result := make([]*pb.Product, 0, len(docs))
+	for _, d := range docs {
+		result = append(result, d.toProto())
+	}
+	return result, nil
+}
+
+// Facets counts in one aggregation. Category counts are rolled up to the
+// ancestors afterwards.
+func (m *MongoStore) Facets(ctx context.Context, pf ProductFilter) ([]*pb.FacetCount, []*pb.FacetCount, error) {
+	count := bson.D{{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: productFilter(ctx, pf)}},
+		{{Key: "$facet", Value: bson.D{
+			{Key: "categories", Value: bson.A{
+				bson.D{{Key: "$match", Value: bson.D{{Key: "category", Value: bson.D{{Key: "$gt", Value: ""}}}}}},
+				bson.D{{Key: "$group", Value: append(bson.D{{Key: "_id", Value: "$category"}}, count...)}},
+			}},
+			{Key: "tags", Value: bson.A{
+				bson.D{{Key: "$unwind", Value: "$tags"}},
+				bson.D{{Key: "$group", Value: append(bson.D{{Key: "_id", Value: "$tags"}}, count...)}},
+			}},
+		}}},
+	}
+	cur, err := m.Coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, nil, err
+	}
+	type bucket struct {
+		Value string `bson:"_id"`
+		Count int64  `bson:"count"`
+	}
+	var result []struct {
+		Categories []bucket `bson:"categories"`
+		Tags       []bucket `bson:"tags"`
+	}
+	if err := cur.All(ctx, &result); err != nil {
+		return nil, nil, err
+	}
+	counter := newFacetCounter()
+	for _, r := range result {
+		for _, b := range r.Categories {
+			counter.addCategory(b.Value, b.Count)
+		}
+		for _, b := range r.Tags {
+			counter.tags[b.Value] += b.Count
+		}
+	}
+	categories, tags := counter.result()
+	return categories, tags, nil
+}
+
+// Search uses the text index for whole word queries. Prefix queries can
+// not use it, so they match words by regular expression and are ranked
+// like the in-memory store does.
+func (m *MongoStore) Search(ctx context.Context, query string, prefix bool, pf ProductFilter, limit int) ([]*pb.SearchResult, error) {
+	if prefix {
+		return m.searchPrefix(ctx, tokenize(query), pf, limit)
+	}
+	filter := productFilter(ctx, pf, bson.E{Key: "$text", Value: bson.D{{Key: "$search", Value: query}}})
+	score := bson.D{{Key: "score", Value: bson.D{{Key: "$meta", Value: "textScore"}}}}
+	opts := options.Find().SetProjection(score).SetSort(score).SetLimit(int64(limit))
+	cur, err := m.Coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var docs []struct {
+		productDocument `bson:",inline"`
+		Score           float64 `bson:"score"`
+	}
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	results := make([]*pb.SearchResult, 0, len(docs))
+	for _, d := range docs {
+		results = append(results, &pb.SearchResult{Product: d.toProto(), Score: d.Score})
+	}
+	return results, nil
+}
+
+// prefixPattern matches words starting with term. \b only knows ASCII
+// word characters; this matches the word boundaries of tokenize, so
+// non-Latin words are found too.
+func prefixPattern(term string) string {
+	return `(^|[^\p{L}\p{N}])` + regexp.QuoteMeta(term)
+}
+
+func (m *MongoStore) searchPrefix(ctx context.Context, terms []string, pf ProductFilter, limit int) ([]*pb.SearchResult, error) {
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	var or bson.A
+	for _, t := range terms {
+		re := bson.Regex{Pattern: prefixPattern(t), Options: "i"}
+		or = append(or,
+			bson.D{{Key: "name", Value: re}},
+			bson.D{{Key: "description", Value: re}},
+		)
+	}
+	opts := options.Find().SetLimit(maxPageSize)
+	cur, err := m.Coll.Find(ctx, productFilter(ctx, pf, bson.E{Key: "$or", Value: or}), opts)
+	if err != nil {
+		return nil, err
+	}
+	var docs []productDocument
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	results := make([]*pb.SearchResult, 0, len(docs))
+	for _, d := range docs {
+		p := d.toProto()
+		if score := scoreProduct(p, terms, true); score > 0 {
+			results = append(results, &pb.SearchResult{Product: p, Score: score})
+		}
+	}
+	sortResults(results)
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// Claim relies on the unique key index created by the migrations. Expired
+// records are removed by a TTL index, which runs lazily, so they are also
+// taken over here.
+func (m *MongoStore) Claim(ctx context.Context, rec IdempotencyRecord) (IdempotencyRecord, bool, error) {
+	_, err := m.Keys.InsertOne(ctx, rec)
+	if err == nil {
+		return rec, true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return IdempotencyRecord{}, false, err
+	}
+	expired := bson.D{
+		{Key: "key", Value: rec.Key},
+		{Key: "expires_at", Value: bson.D{{Key: "$lte", Value: time.Now()}}},
+	}
+	res, err := m.Keys.ReplaceOne(ctx, expired, rec)
+	if err != nil {
+		return IdempotencyRecord{}, false, err
+	}
+	if res.MatchedCount == 1 {
+		return rec, true, nil
+	}
+	var existing IdempotencyRecord
+	if err := m.Keys.FindOne(ctx, bson.D{{Key: "key", Value: rec.Key}}).Decode(&existing); err != nil {
+		return IdempotencyRecord{}, false, err
+	}
+	return existing, false, nil
+}
+
+func (m *MongoStore) Complete(ctx context.Context, key string) error {
+	_, err := m.Keys.UpdateOne(ctx,
+		bson.D{{Key: "key", Value: key}},
+		bson.D{{Key: "$unset", Value: bson.D{{Key: "pending", Value: ""}}}},
+	)
+	return err
+}
+
+func (m *MongoStore) Release(ctx context.Context, key string) error {
+	_, err := m.Keys.DeleteOne(ctx, bson.D{{Key: "key", Value: key}})
+	return err
+}
+
+func (m *MongoStore) AddCategory(ctx context.Context, c *pb.Category) error {
+	doc := categoryDocument{
+		Tenant:      TenantFromContext(ctx),
+		Path:        c.Path,
+		DisplayName: c.DisplayName,
+		CreatedAt:   now(),
+	}
+	if _, err := m.Categories.InsertOne(ctx, doc); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrCategoryExists
+		}
+		return err
+	}
+	c.CreateTime = toTimestamp(doc.CreatedAt)
+	return nil
+}
+
+func (m *MongoStore) GetCategory(ctx context.Context, path string) (*pb.Category, error) {
+	var result categoryDocument
+	err := m.Categories.FindOne(ctx, tenantFilter(ctx, bson.E{Key: "path", Value: path})).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrCategoryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result.toProto(), nil
+}
+
+func (m *MongoStore) UpdateCategory(ctx context.Context, c *pb.Category) error {
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "display_name", Value: c.DisplayName}}}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var result categoryDocument
+	err := m.Categories.FindOneAndUpdate(ctx, tenantFilter(ctx, bson.E{Key: "path", Value: c.Path}), update, opts).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return ErrCategoryNotFound
+	}
+	if err != nil {
+		return err
+	}
+	c.CreateTime = toTimestamp(result.CreatedAt)
+	return nil
+}
+
+func (m *MongoStore) DeleteCategory(ctx context.Context, path string) error {
+	res, err := m.Categories.DeleteOne(ctx, tenantFilter(ctx, bson.E{Key: "path", Value: path}))
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
+
+func (m *MongoStore) ListCategories(ctx context.Context, parent string, recursive bool) ([]*pb.Category, error) {
+	prefix := ""
+	if parent != "" {
+		prefix = regexp.QuoteMeta(parent) + "/"
+	}
+	pattern := "^" + prefix + "[^/]+$"
+	if recursive {
+		pattern = "^" + prefix
+	}
+	filter := tenantFilter(ctx, bson.E{Key: "path", Value: bson.Regex{Pattern: pattern}})
+	cur, err := m.Categories.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "path", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []categoryDocument
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	result := make([]*pb.Category, 0, len(docs))
+	for _, d := range docs {
+		result = append(result, d.toProto())
+	}
+	return result, nil
+}
+
+// AddVariant relies on the unique tenant and sku index created by the
+// migrations.
+func (m *MongoStore) AddVariant(ctx context.Context, v *pb.Variant) error {
+	doc := variantDocument{
+		Tenant:     TenantFromContext(ctx),
+		ID:         v.Id,
+		ProductID:  v.ProductId,
+		SKU:        v.Sku,
+		Attributes: v.Attributes,
+		CreatedAt:  now(),
+	}
+	doc.UpdatedAt = doc.CreatedAt
+	if _, err := m.Variants.InsertOne(ctx, doc); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrSKUExists
+		}
+		return err
+	}
+	v.CreateTime = toTimestamp(doc.CreatedAt)
+	v.UpdateTime = v.CreateTime
+	return nil
+}
+
+func (m *MongoStore) GetVariant(ctx context.Context, id string) (*pb.Variant, error) {
+	var result variantDocument
+	err := m.Variants.FindOne(ctx, tenantFilter(ctx, bson.E{Key: "id", Value: id})).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrVariantNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result.toProto(), nil
+}
+
+func (m *MongoStore) UpdateVariant(ctx context.Context, v *pb.Variant) error {
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "sku", Value: v.Sku},
+		{Key: "attributes", Value: v.Attributes},
+		{Key: "updated_at", Value: now()},
+	}}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var result variantDocument
+	err := m.Variants.FindOneAndUpdate(ctx, tenantFilter(ctx, bson.E{Key: "id", Value: v.Id}), update, opts).Decode(&result)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrSKUExists
+	}
+	if err == mongo.ErrNoDocuments {
+		return ErrVariantNotFound
+	}
+	if err != nil {
+		return err
+	}
+	proto.Reset(v)
+	proto.Merge(v, result.toProto())
+	return nil
+}
+
+func (m *MongoStore) DeleteVariant(ctx context.Context, id string) error {
+	res, err := m.Variants.DeleteOne(ctx, tenantFilter(ctx, bson.E{Key: "id", Value: id}))
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrVariantNotFound
+	}
+	return nil
+}
+
+func (m *MongoStore) ListVariants(ctx context.Context, productID string) ([]*pb.Variant, error) {
+	filter := tenantFilter(ctx, bson.E{Key: "product_id", Value: productID})
+	cur, err := m.Variants.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "sku", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []variantDocument
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	result := make([]*pb.Variant, 0, len(docs))
+	for _, d := range docs {
+		result = append(result, d.toProto())
+	}
+	return result, nil
+}
+
+func (m *MongoStore) DeleteProductVariants(ctx context.Context, productID string) error {
+	_, err := m.Variants.DeleteMany(ctx, tenantFilter(ctx, bson.E{Key: "product_id", Value: productID}))
+	return err
+}
+
+func (m *MongoStore) AddPrice(ctx context.Context, pc *pb.PriceChange) error {
+	_, err := m.Prices.InsertOne(ctx, priceDocument{
+		Tenant:      TenantFromContext(ctx),
+		ID:          pc.Id,
+		ProductID:   pc.ProductId,
+		Price:       newMoneyDocument(pc.Price),
+		EffectiveAt: fromTimestamp(pc.EffectiveTime),
+		CreatedAt:   fromTimestamp(pc.CreateTime),
+	})
+	return err
+}
+
+func (m *MongoStore) DeletePrice(ctx context.Context, id string) error {
+	res, err := m.Prices.DeleteOne(ctx, tenantFilter(ctx,
+		bson.E{Key: "id", Value: id},
+		bson.E{Key: "applied", Value: false},
+	))
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 1 {
+		return nil
+	}
+	var result priceDocument
+	err = m.Prices.FindOne(ctx, tenantFilter(ctx, bson.E{Key: "id", Value: id})).Decode(&result)
+	switch {
+	case err == mongo.ErrNoDocuments:
+		return ErrPriceNotFound
+	case err != nil:
+		return err
+	default:
+		return ErrPriceApplied
+	}
+}
+
+func (m *MongoStore) ListPrices(ctx context.Context, productID string) ([]*pb.PriceChange, error) {
+	filter := tenantFilter(ctx, bson.E{Key: "product_id", Value: productID})
+	opts := options.Find().SetSort(bson.D{{Key: "effective_at", Value: 1}, {Key: "created_at", Value: 1}})
+	cur, err := m.Prices.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var docs []priceDocument
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	result := make([]*pb.PriceChange, 0, len(docs))
+	for _, d := range docs {
+		result = append(result, d.toProto())
+	}
+	return result, nil
+}
+
+func (m *MongoStore) PriceAt(ctx context.Context, productID string, t time.Time) (*pb.PriceChange, error) {
+	return m.priceAt(ctx, TenantFromContext(ctx), productID, t)
+}
+
+func (m *MongoStore) priceAt(ctx context.Context, tenant, productID string, t time.Time) (*pb.PriceChange, error) {
+	filter := bson.D{
+		{Key: "tenant", Value: tenant},
+		{Key: "product_id", Value: productID},
+		{Key: "effective_at", Value: bson.D{{Key: "$lte", Value: t}}},
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "effective_at", Value: -1}, {Key: "created_at", Value: -1}})
+	var result priceDocument
+	err := m.Prices.FindOne(ctx, filter, opts).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNoPrice
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result.toProto(), nil
+}
+
+// ApplyPrices updates the products before marking the changes applied, so
+// an interrupted run is completed by the next one.
+func (m *MongoStore) ApplyPrices(ctx context.Context, now time.Time) ([]ProductRef, error) {
+	due := bson.D{
+		{Key: "applied", Value: false},
+		{Key: "effective_at", Value: bson.D{{Key: "$lte", Value: now}}},
+	}
+	cur, err := m.Prices.Find(ctx, due)
+	if err != nil {
+		return nil, err
+	}
+	var docs []priceDocument
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	ids := make(map[ProductRef][]string)
+	var refs []ProductRef
+	for _, d := range docs {
+		ref := ProductRef{Tenant: d.Tenant, ID: d.ProductID}
+		if _, ok := ids[ref]; !ok {
+			refs = append(refs, ref)
+		}
+		ids[ref] = append(ids[ref], d.ID)
+	}
+	var applied []ProductRef
+	for _, ref := range refs {
+		pc, err := m.priceAt(ctx, ref.Tenant, ref.ID, now)
+		if err != nil {
+			return applied, err
+		}
+		update := bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "price", Value: newMoneyDocument(pc.Price)},
+				{Key: "updated_at", Value: now},
+			}},
+			{Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}},
+		}
+		filter := bson.D{{Key: "tenant", Value: ref.Tenant}, {Key: "id", Value: ref.ID}, notDeleted}
+		res, err := m.Coll.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return applied, err
+		}
+		if res.MatchedCount == 0 {
+			// The product is deleted, its changes are applied once it
+			// is restored.
+			continue
+		}
+		_, err = m.Prices.UpdateMany(ctx,
+			bson.D{{Key: "id", Value: bson.D{{Key: "$in", Value: ids[ref]}}}},
+			bson.D{{Key: "$set", Value: bson.D{{Key: "applied", Value: true}}}},
+		)
+		if err != nil {
+			return applied, err
+		}
+		applied = append(applied, ref)
+	}
+	return applied, nil
+}
+
+func (m *MongoStore) DeleteProductPrices(ctx context.Context, productID string) error {
+	_, err := m.Prices.DeleteMany(ctx, tenantFilter(ctx, bson.E{Key: "product_id", Value: productID}))
+	return err
+}
+
+func (m *MongoStore) AppendAudit(ctx context.Context, e *pb.AuditEvent) error {
+	doc := newAuditDocument(e)
+	doc.Tenant = TenantFromContext(ctx)
+	_, err := m.Audit.InsertOne(ctx, doc)
+	return err
+}
+
+func (m *MongoStore) ListAudit(ctx context.Context, productID, after string, limit int) ([]*pb.AuditEvent, error) {
+	filter := tenantFilter(ctx, bson.E{Key: "product_id", Value: productID})
+	if after != "" {
+		filter = append(filter, bson.E{Key: "id", Value: bson.D{{Key: "$gt", Value: after}}})
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "id", Value: 1}}).SetLimit(int64(limit))
+	cur, err := m.Audit.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var docs []auditDocument
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	result := make([]*pb.AuditEvent, 0, len(docs))
+	for _, d := range docs {
+		result = append(result, d.toProto())
+	}
+	return result, nil
+}
+
+// changeEvent is the part of a MongoDB change stream event used by Watch.
+type changeEvent struct {
+	ID                       bson.Raw         `bson:"_id"`
+	OperationType            string           `bson:"operationType"`
+	ClusterTime              bson.Timestamp   `bson:"clusterTime"`
+	FullDocument             *productDocument `bson:"fullDocument"`
+	FullDocumentBeforeChange *productDocument `bson:"fullDocumentBeforeChange"`
+}
+
+// changeStreamHistoryLost is the server error code for a resume token that
+// has fallen off the oplog.
+const changeStreamHistoryLost = 286
+
+// Watch follows the collection change stream. Delete events carry the
+// removed product only when change stream pre-images are enabled on the
+// collection, see the migrations package. Events are matched to the
+// tenant of ctx by the product before or after the change. A soft delete
+// is reported as DELETED and the later purge is not reported again.
+func (m *MongoStore) Watch(ctx context.Context, resumeToken string, fn func(*pb.ProductEvent) error) error {
+	tenant := TenantFromContext(ctx)
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "operationType", Value: bson.D{
+				{Key: "$in", Value: bson.A{"insert", "update", "replace", "delete"}},
+			}},
+			{Key: "$or", Value: bson.A{
+				bson.D{{Key: "fullDocument.tenant", Value: tenant}},
+				bson.D{{Key: "fullDocumentBeforeChange.tenant", Value: tenant}},
+			}},
+		}}},
+	}
+	opts := options.ChangeStream().
+		SetFullDocument(options.UpdateLookup).
+		SetFullDocumentBeforeChange(options.WhenAvailable)
+	if resumeToken != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(resumeToken)
+		if err != nil || bson.Raw(raw).Validate() != nil {
+			return ErrInvalidResumeToken
+		}
+		opts.SetResumeAfter(bson.Raw(raw))
+	}
+	cs, err := m.Coll.Watch(ctx, pipeline, opts)
+	if err != nil {
+		var se mongo.ServerError
+		if errors.As(err, &se) && se.HasErrorCode(changeStreamHistoryLost) {
+			return ErrResumeTokenExpired
+		}
+		return err
+	}
+	defer cs.Close(context.Background())
+	for cs.Next(ctx) {
+		var ev changeEvent
+		if err := cs.Decode(&ev); err != nil {
+			return err
+		}
+		e := &pb.ProductEvent{
+			ResumeToken: base64.RawURLEncoding.EncodeToString(ev.ID),
+			EventTime:   toTimestamp(time.Unix(int64(ev.ClusterTime.T), 0)),
+		}
+		doc := ev.FullDocument
+		switch ev.OperationType {
+		case "insert":
+			e.Type = pb.ProductEvent_CREATED
+		case "update", "replace":
+			e.Type = pb.ProductEvent_UPDATED
+			if doc != nil && !doc.DeletedAt.IsZero() {
+				e.Type = pb.ProductEvent_DELETED
+			}
+		case "delete":
+			e.Type = pb.ProductEvent_DELETED
+			doc = ev.FullDocumentBeforeChange
+			if doc != nil && !doc.DeletedAt.IsZero() {
+				continue
+			}
+		}
+		if doc != nil {
+			e.Product = doc.toProto()
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return cs.Err()
+}
+
+func (m *MongoStore) Ping(ctx context.Context) error {
+	return m.DB.Ping(ctx, nil)
+}
+
+func (m *MongoStore) Close(ctx context.Context) error {
+	return m.DB.Disconnect(ctx)
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 1000
+	exportBatchSize = 500
+)
+
+// pageToken is the cursor handed out as an opaque next_page_token.
+// Products are listed in id order, so the last returned id is enough
+// to resume.
+type pageToken struct {
+	After string `json:"a"`
+}
+
+func encodePageToken(t pageToken) string {
+	b, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodePageToken(s string) (pageToken, error) {
+	var t pageToken
+	if s == "" {
+		return t, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return t, err
+	}
+	err = json.Unmarshal(b, &t)
+	return t, err
+}
+
+func pageSize(n int32) int {
+	switch {
+	case n <= 0:
+		return defaultPageSize
+	case n > maxPageSize:
+		return maxPageSize
+	}
+	return int(n)
+}
@@ -0,0 +1,41 @@
+package storage
+
+import "testing"
+
+func TestPageTokenRoundTrip(t *testing.T) {
+	want := pageToken{After: "0190b6c2-7f3a-7000-8000-000000000000"}
+	got, err := decodePageToken(encodePageToken(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestDecodePageToken(t *testing.T) {
+	if got, err := decodePageToken(""); err != nil || got != (pageToken{}) {
+		t.Fatalf("empty token: got %v, %v", got, err)
+	}
+	for _, token := range []string{"%%%", encodePageToken(pageToken{})[:1] + "!"} {
+		if _, err := decodePageToken(token); err == nil {
+			t.Errorf("token %q was accepted", token)
+		}
+	}
+}
+
+func TestPageSize(t *testing.T) {
+	for _, tt := range []struct {
+		in   int32
+		want int
+	}{
+		{0, defaultPageSize},
+		{-1, defaultPageSize},
+		{10, 10},
+		{maxPageSize + 1, maxPageSize},
+	} {
+		if got := pageSize(tt.in); got != tt.want {
+			t.Errorf("pageSize(%d) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"time"
+
+	pb "service/sappgrpc"
+
+	"github.com/gofrs/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// PriceCollectionName is the MongoDB collection of price histories.
+const PriceCollectionName = "prices"
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// PriceStore keeps the price history of products. Like ProductStore every
+// call except ApplyPrices acts on the tenant set on ctx.
+type PriceStore interface {
+	AddPrice(ctx context.Context, pc *pb.PriceChange) error
+	// DeletePrice removes a price change that has not been applied yet.
+	// Applied changes fail with ErrPriceApplied.
+	DeletePrice(ctx context.Context, id string) error
+	// ListPrices returns the history of a product ordered by effective
+	// time.
+	ListPrices(ctx context.Context, productID string) ([]*pb.PriceChange, error)
+	// PriceAt returns the price change in effect at t, or ErrNoPrice.
+	PriceAt(ctx context.Context, productID string, t time.Time) (*pb.PriceChange, error)
+	// ApplyPrices marks all changes of all tenants that are effective at
+	// now as applied and sets the price of their products to the price in
+	// effect. It returns the products whose price was set. Changes of
+	// deleted products stay pending, so they take effect on restore.
+	ApplyPrices(ctx context.Context, now time.Time) ([]ProductRef, error)
+	// DeleteProductPrices removes the history of a product.
+	DeleteProductPrices(ctx context.Context, productID string) error
+}
+
+// cacheInvalidator is implemented by stores that need to learn about
+// products changed behind their back.
+type cacheInvalidator interface {
+	Invalidate(ctx context.Context, id string)
+}
+
+func validateMoney(m *pb.Money) error {
+	if m == nil {
+		return status.Errorf(codes.InvalidArgument, "Price is required")
+	}
+	if !currencyCode.MatchString(m.CurrencyCode) {
+		return status.Errorf(codes.InvalidArgument, "Invalid currency code: %q", m.CurrencyCode)
+	}
+	if m.Units < 0 || m.Nanos < 0 || m.Nanos > 999_999_999 {
+		return status.Errorf(codes.InvalidArgument, "Price must be a non-negative amount with nanos below one unit")
+	}
+	return nil
+}
+
+// currentPrice picks the change in effect at t from a history ordered by
+// effective time. Changes taking effect at the same time are decided by
+// their creation.
+func currentPrice(history []*pb.PriceChange, t time.Time) *pb.PriceChange {
+	var result *pb.PriceChange
+	for _, pc := range history {
+		if fromTimestamp(pc.EffectiveTime).After(t) {
+			break
+		}
+		result = pc
+	}
+	return result
+}
+
+// sortPrices orders a history by effective and then creation time.
+func sortPrices(history []*pb.PriceChange) {
+	sort.SliceStable(history, func(i, j int) bool {
+		ei, ej := fromTimestamp(history[i].EffectiveTime), fromTimestamp(history[j].EffectiveTime)
+		if !ei.Equal(ej) {
+			return ei.Before(ej)
+		}
+		return fromTimestamp(history[i].CreateTime).Before(fromTimestamp(history[j].CreateTime))
+	})
+}
+
+// ApplyDuePrices applies the price changes that have become effective and
+// drops the affected products from the cache in front of the store.
+func (c *ProductService) ApplyDuePrices(ctx context.Context) (int, error) {
+	if c.prices == nil {
+		return 0, nil
+	}
+	refs, err := c.prices.ApplyPrices(ctx, now())
+	if inv, ok := c.Store.(cacheInvalidator); ok {
+		for _, ref := range refs {
+			inv.Invalidate(WithTenant(ctx, ref.Tenant), ref.ID)
+		}
+	}
+	return len(refs), err
+}
+
+// SchedulePriceChange adds a change to the price history. A change that
+// is already effective is applied right away.
+func (c *ProductService) SchedulePriceChange(ctx context.Context, req *pb.PriceChange) (*pb.PriceChange, error) {
+	if c.prices == nil {
+		return nil, status.Errorf(codes.Unimplemented, "Prices are not configured")
+	}
+	if err := validateMoney(req.Price); err != nil {
+		return nil, err
+	}
+	if _, err := c.Store.Get(ctx, req.ProductId); err != nil {
+		return nil, toStatus(err, "get", req.ProductId)
+	}
+	out, err := uuid.NewV4()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Error while generating price change ID: %v", err)
+	}
+	ts := now()
+	pc := &pb.PriceChange{
+		Id:            out.String(),
+		ProductId:     req.ProductId,
+		Price:         req.Price,
+		EffectiveTime: req.EffectiveTime,
+		CreateTime:    toTimestamp(ts),
+	}
+	if pc.EffectiveTime == nil {
+		pc.EffectiveTime = pc.CreateTime
+	} else {
+		pc.EffectiveTime = toTimestamp(fromTimestamp(pc.EffectiveTime).UTC().Truncate(time.Millisecond))
+	}
+	if err := c.prices.AddPrice(ctx, pc); err != nil {
+		return nil, resourceStatus(err, priceResource, "schedule", pc.Id)
+	}
+	if !fromTimestamp(pc.EffectiveTime).After(ts) {
+		if _, err := c.ApplyDuePrices(ctx); err != nil {
+			return nil, resourceStatus(err, priceResource, "apply", pc.Id)
+		}
+		pc.Applied = true
+	}
+	return pc, nil
+}
+
+func (c *ProductService) CancelPriceChange(ctx context.Context, req *pb.PriceChangeID) (*emptypb.Empty, error) {
+	if c.prices == nil {
+		return nil, status.Errorf(codes.Unimplemented, "Prices are not configured")
+	}
+	if err := c.prices.DeletePrice(ctx, req.Value); err != nil {
+		return nil, resourceStatus(err, priceResource, "cancel", req.Value)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (c *ProductService) ListPriceHistory(ctx context.Context, req *pb.ProductID) (*pb.ListPriceHistoryResponse, error) {
+	if c.prices == nil {
+		return nil, status.Errorf(codes.Unimplemented, "Prices are not configured")
+	}
+	if _, err := c.Store.Get(ctx, req.Value); err != nil {
+		return nil, toStatus(err, "get", req.Value)
+	}
+	history, err := c.prices.ListPrices(ctx, req.Value)
+	if err != nil {
+		return nil, resourceStatus(err, priceResource, "list", "")
+	}
+	return &pb.ListPriceHistoryResponse{Changes: history}, nil
+}
+
+func (c *ProductService) GetPriceAt(ctx context.Context, req *pb.GetPriceAtRequest) (*pb.PriceChange, error) {
+	if c.prices == nil {
+		return nil, status.Errorf(codes.Unimplemented, "Prices are not configured")
+	}
+	if _, err := c.Store.Get(ctx, req.ProductId); err != nil {
+		return nil, toStatus(err, "get", req.ProductId)
+	}
+	t := now()
+	if req.Time != nil {
+		t = req.Time.AsTime()
+	}
+	pc, err := c.prices.PriceAt(ctx, req.ProductId, t)
+	if errors.Is(err, ErrNoPrice) {
+		return nil, status.Errorf(codes.NotFound, "Product %s has no price at %s", req.ProductId, t.Format(time.RFC3339))
+	}
+	if err != nil {
+		return nil, resourceStatus(err, priceResource, "get", "")
+	}
+	return pc, nil
+}
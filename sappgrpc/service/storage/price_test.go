@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	pb "service/sappgrpc"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestCurrentPrice(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	change := func(id string, effective, created time.Duration) *pb.PriceChange {
+		return &pb.PriceChange{
+			Id:            id,
+			EffectiveTime: toTimestamp(base.Add(effective)),
+			CreateTime:    toTimestamp(base.Add(created)),
+		}
+	}
+	history := []*pb.PriceChange{
+		change("later-created", time.Hour, 2*time.Minute),
+		change("first", 0, 0),
+		change("earlier-created", time.Hour, time.Minute),
+		change("future", 2*time.Hour, 0),
+	}
+	sortPrices(history)
+
+	for _, tt := range []struct {
+		at   time.Duration
+		want string
+	}{
+		{-time.Minute, ""},
+		{0, "first"},
+		{30 * time.Minute, "first"},
+		{time.Hour, "later-created"},
+		{3 * time.Hour, "future"},
+	} {
+		got := currentPrice(history, base.Add(tt.at))
+		if got.GetId() != tt.want {
+			t.Errorf("at %v: got %q, want %q", tt.at, got.GetId(), tt.want)
+		}
+	}
+}
+
+func TestApplyPricesWaitsForRestore(t *testing.T) {
+	m := NewMemoryStore()
+	c := NewProductService(m, WithPrices(m))
+	ctx := tenantContext("acme")
+	addProducts(t, m, ctx, "kettle")
+	if _, err := c.DeleteProduct(ctx, &pb.ProductID{Value: "kettle"}); err != nil {
+		t.Fatal(err)
+	}
+	price := &pb.Money{CurrencyCode: "EUR", Units: 30}
+	err := m.AddPrice(ctx, &pb.PriceChange{
+		Id:            "pc",
+		ProductId:     "kettle",
+		Price:         price,
+		EffectiveTime: toTimestamp(now().Add(-time.Minute)),
+		CreateTime:    toTimestamp(now().Add(-time.Hour)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := c.ApplyDuePrices(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Fatalf("applied %d prices to a deleted product", n)
+	}
+	history, err := m.ListPrices(ctx, "kettle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].Applied {
+		t.Fatalf("got history %v, want the change pending", history)
+	}
+
+	p, err := c.RestoreProduct(ctx, &pb.ProductID{Value: "kettle"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(p.Price, price) {
+		t.Fatalf("got price %v after restore, want %v", p.Price, price)
+	}
+	history, err = m.ListPrices(ctx, "kettle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !history[0].Applied {
+		t.Fatal("change is still pending after restore")
+	}
+}
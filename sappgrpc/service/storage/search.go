@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	pb "service/sappgrpc"
+)
+
+// Field weights used for ranking, matching the text index weights.
+const (
+	nameWeight        = 2
+	descriptionWeight = 1
+)
+
+// tokenize splits s into lower-cased words.
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// productTerms returns the weighted terms of a product.
+func productTerms(p *pb.Product) map[string]int {
+	terms := make(map[string]int)
+	for _, t := range tokenize(p.Name) {
+		terms[t] += nameWeight
+	}
+	for _, t := range tokenize(p.Description) {
+		terms[t] += descriptionWeight
+	}
+	return terms
+}
+
+// scoreProduct ranks p against the query terms. Every matching word adds
+// its field weight; a query term may match several words by prefix.
+func scoreProduct(p *pb.Product, query []string, prefix bool) float64 {
+	var score float64
+	for term, w := range productTerms(p) {
+		for _, q := range query {
+			if term == q || (prefix && strings.HasPrefix(term, q)) {
+				score += float64(w)
+			}
+		}
+	}
+	return score
+}
+
+func sortResults(results []*pb.SearchResult) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Product.Id < results[j].Product.Id
+	})
+}
+
+// invertedIndex maps terms to the weighted ids of the products containing
+// them. It is not safe for concurrent use.
+type invertedIndex struct {
+	postings map[string]map[string]int
+}
+
+func newInvertedIndex() *invertedIndex {
+	return &invertedIndex{postings: make(map[string]map[string]int)}
+}
+
+func (ix *invertedIndex) add(p *pb.Product) {
+	for term, w := range productTerms(p) {
+		ids, ok := ix.postings[term]
+		if !ok {
+			ids = make(map[string]int)
+			ix.postings[term] = ids
+		}
+		ids[p.Id] = w
+	}
+}
+
+func (ix *invertedIndex) remove(p *pb.Product) {
+	for term := range productTerms(p) {
+		delete(ix.postings[term], p.Id)
+		if len(ix.postings[term]) == 0 {
+			delete(ix.postings, term)
+		}
+	}
+}
+
+// search returns the accumulated score per product id.
+func (ix *invertedIndex) search(query []string, prefix bool) map[string]float64 {
+	scores := make(map[string]float64)
+	for _, q := range query {
+		if !prefix {
+			for id, w := range ix.postings[q] {
+				scores[id] += float64(w)
+			}
+			continue
+		}
+		for term, ids := range ix.postings {
+			if strings.HasPrefix(term, q) {
+				for id, w := range ids {
+					scores[id] += float64(w)
+				}
+			}
+		}
+	}
+	return scores
+}
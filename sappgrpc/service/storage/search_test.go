@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"regexp"
+	"testing"
+
+	pb "service/sappgrpc"
+)
+
+func TestTokenize(t *testing.T) {
+	got := tokenize("Electric-Kettle, 1.7 l — Чайник!")
+	want := []string{"electric", "kettle", "1", "7", "l", "чайник"}
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestScoreProduct(t *testing.T) {
+	p := &pb.Product{Name: "Electric Kettle", Description: "A kettle with electric heating"}
+	for _, tt := range []struct {
+		query  string
+		prefix bool
+		want   float64
+	}{
+		{"kettle", false, nameWeight + descriptionWeight},
+		{"electric kettle", false, 2 * (nameWeight + descriptionWeight)},
+		{"heating", false, descriptionWeight},
+		{"heat", false, 0},
+		{"heat", true, descriptionWeight},
+		// A prefix may match several words of a field.
+		{"e", true, nameWeight + descriptionWeight},
+		{"toaster", true, 0},
+	} {
+		if got := scoreProduct(p, tokenize(tt.query), tt.prefix); got != tt.want {
+			t.Errorf("query %q (prefix %v): got score %v, want %v", tt.query, tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestInvertedIndexScoresLikeScoreProduct(t *testing.T) {
+	products := []*pb.Product{
+		{Id: "1", Name: "Electric Kettle", Description: "Boils water"},
+		{Id: "2", Name: "Kettle", Description: "For the stove"},
+		{Id: "3", Name: "Toaster", Description: "Electric, two slots"},
+	}
+	ix := newInvertedIndex()
+	for _, p := range products {
+		ix.add(p)
+	}
+	for _, query := range []string{"kettle", "electric", "electric kettle", "st", "ke"} {
+		for _, prefix := range []bool{false, true} {
+			scores := ix.search(tokenize(query), prefix)
+			for _, p := range products {
+				if want := scoreProduct(p, tokenize(query), prefix); scores[p.Id] != want {
+					t.Errorf("query %q (prefix %v), product %s: got %v, want %v", query, prefix, p.Id, scores[p.Id], want)
+				}
+			}
+		}
+	}
+
+	ix.remove(products[0])
+	if scores := ix.search([]string{"electric"}, false); len(scores) != 1 || scores["3"] == 0 {
+		t.Fatalf("got scores %v after remove", scores)
+	}
+	if _, ok := ix.postings["boils"]; ok {
+		t.Fatal("removed product left an empty posting")
+	}
+}
+
+func searchIDs(t *testing.T, m *MemoryStore, tenant, query string, prefix bool) []string {
+	t.Helper()
+	results, err := m.Search(tenantContext(tenant), query, prefix, ProductFilter{}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.Product.Id
+	}
+	return ids
+}
+
+func wantIDs(t *testing.T, what string, got []string, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: got %v, want %v", what, got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("%s: got %v, want %v", what, got, want)
+		}
+	}
+}
+
+func TestMemoryStoreSearch(t *testing.T) {
+	m := NewMemoryStore()
+	c := NewProductService(m)
+	ctx := tenantContext("acme")
+	for _, p := range []*pb.Product{
+		{Id: "1", Name: "Teapot", Description: "Pairs well with a kettle"},
+		{Id: "2", Name: "Electric Kettle", Description: "Boils water"},
+		{Id: "3", Name: "Kettle", Description: "Stove top kettle"},
+	} {
+		if err := m.Add(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.Add(tenantContext("globex"), &pb.Product{Id: "4", Name: "Kettle"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Name hits outrank description hits, ties go by id.
+	wantIDs(t, "kettle", searchIDs(t, m, "acme", "kettle", false), "3", "2", "1")
+	wantIDs(t, "ket", searchIDs(t, m, "acme", "ket", false))
+	wantIDs(t, "ket prefix", searchIDs(t, m, "acme", "ket", true), "3", "2", "1")
+	wantIDs(t, "globex", searchIDs(t, m, "globex", "kettle", false), "4")
+
+	p, err := m.Get(ctx, "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Name = "Electric Samovar"
+	if err := m.Update(ctx, p); err != nil {
+		t.Fatal(err)
+	}
+	wantIDs(t, "kettle after update", searchIDs(t, m, "acme", "kettle", false), "3", "1")
+	wantIDs(t, "samovar after update", searchIDs(t, m, "acme", "samovar", false), "2")
+
+	if _, err := c.DeleteProduct(ctx, &pb.ProductID{Value: "3"}); err != nil {
+		t.Fatal(err)
+	}
+	wantIDs(t, "kettle after delete", searchIDs(t, m, "acme", "kettle", false), "1")
+
+	if _, err := c.RestoreProduct(ctx, &pb.ProductID{Value: "3"}); err != nil {
+		t.Fatal(err)
+	}
+	wantIDs(t, "kettle after restore", searchIDs(t, m, "acme", "kettle", false), "3", "1")
+}
+
+func TestPrefixPattern(t *testing.T) {
+	for _, tt := range []struct {
+		term, text string
+		want       bool
+	}{
+		{"ket", "Electric kettle", true},
+		{"ket", "Kettle", true},
+		{"ettle", "Electric kettle", false},
+		{"чай", "Зеленый чай", true},
+		{"ай", "Зеленый чай", false},
+		{"kaffe", "Mahlkaffee-Packung", false},
+		{"pack", "Mahlkaffee-Packung", true},
+		{"1.7", "Volume 1.7 l", true},
+		{"1.7", "Volume 117 l", false},
+	} {
+		re := regexp.MustCompile("(?i)" + prefixPattern(tt.term))
+		if got := re.MatchString(tt.text); got != tt.want {
+			t.Errorf("%q in %q: got %v, want %v", tt.term, tt.text, got, tt.want)
+		}
+	}
+}
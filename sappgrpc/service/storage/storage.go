@@ -2,75 +2,576 @@ package storage
 
 import (
 	"context"
-	"fmt"
-	"log"
-	"os"
+	"errors"
+	"io"
+	"log/slog"
+	"sort"
+	"time"
 
 	pb "service/sappgrpc"
 
-	"github.com/gofrs/uuid"
-	"go.mongodb.org/mongo-driver/v2/bson"
-	"go.mongodb.org/mongo-driver/v2/mongo"
-	"go.mongodb.org/mongo-driver/v2/mongo/options"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
 )
 
+// importBatchSize is the number of streamed products written with one
+// bulk insert by ImportProducts.
+const importBatchSize = 100
+
+// maxUpdateAttempts bounds the retries of a partial update that lost a
+// race with a concurrent write.
+const maxUpdateAttempts = 3
+
 type ProductService struct {
 	pb.UnimplementedProductInfoServer
-	DB   *mongo.Client
-	Coll *mongo.Collection
+	Store ProductStore
+
+	idempotency    IdempotencyStore
+	idempotencyTTL time.Duration
+	blobs          BlobStore
+	categories     CategoryStore
+	variants       VariantStore
+	prices         PriceStore
+	audit          AuditStore
+	ids            IDGenerator
+	clientIDs      bool
+
+	duplicatePolicy    DuplicatePolicy
+	duplicateThreshold float64
 }
 
-func NewProductService() *ProductService {
-	uri := os.Getenv("MONGODB_URI")
-	if uri == "" {
-		log.Fatal("Set your MONGODB_URI environment variable")
+type Option func(*ProductService)
+
+// WithIdempotency makes AddProduct honor the idempotency-key header.
+// Keys are remembered for ttl.
+func WithIdempotency(store IdempotencyStore, ttl time.Duration) Option {
+	return func(c *ProductService) {
+		c.idempotency = store
+		c.idempotencyTTL = ttl
 	}
-	client, err := mongo.Connect(options.Client().ApplyURI(uri))
-	if err != nil {
-		panic(err)
+}
+
+// WithBlobStore enables product asset uploads and downloads.
+func WithBlobStore(blobs BlobStore) Option {
+	return func(c *ProductService) {
+		c.blobs = blobs
 	}
-	coll := client.Database("fevse").Collection("storage")
-	return &ProductService{
-		DB:   client,
-		Coll: coll,
+}
+
+// WithCategories enables the category tree RPCs and makes product writes
+// check that the category of a product exists.
+func WithCategories(categories CategoryStore) Option {
+	return func(c *ProductService) {
+		c.categories = categories
 	}
 }
 
-func (c *ProductService) Disconnect(ctx context.Context) error {
-	if err := c.DB.Disconnect(ctx); err != nil {
-		return err
+// WithVariants enables the variant RPCs and makes GetProduct return the
+// variants of a product.
+func WithVariants(variants VariantStore) Option {
+	return func(c *ProductService) {
+		c.variants = variants
+	}
+}
+
+// WithPrices enables the price RPCs.
+func WithPrices(prices PriceStore) Option {
+	return func(c *ProductService) {
+		c.prices = prices
 	}
-	return nil
+}
+
+// WithAudit records every product change in an audit trail and enables
+// ListProductAuditEvents.
+func WithAudit(audit AuditStore) Option {
+	return func(c *ProductService) {
+		c.audit = audit
+	}
+}
+
+// WithIDGenerator replaces the UUIDv4 generator of product ids.
+func WithIDGenerator(ids IDGenerator) Option {
+	return func(c *ProductService) {
+		c.ids = ids
+	}
+}
+
+// WithClientIDs makes AddProduct keep the id sent by the client if it has
+// the format of the configured generator. Without it the id is ignored.
+func WithClientIDs() Option {
+	return func(c *ProductService) {
+		c.clientIDs = true
+	}
+}
+
+// WithDuplicateCheck makes AddProduct look for a stored product with the
+// same normalized name, or a name and description similarity of at least
+// threshold, and handle it according to policy. The threshold is also the
+// default of FindDuplicates.
+func WithDuplicateCheck(policy DuplicatePolicy, threshold float64) Option {
+	return func(c *ProductService) {
+		c.duplicatePolicy = policy
+		c.duplicateThreshold = threshold
+	}
+}
+
+func NewProductService(store ProductStore, opts ...Option) *ProductService {
+	c := &ProductService{
+		Store:          store,
+		idempotencyTTL: DefaultIdempotencyTTL,
+		ids:            UUIDv4{},
+
+		duplicateThreshold: DefaultDuplicateThreshold,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 func (c *ProductService) GetProduct(ctx context.Context, in *pb.ProductID) (*pb.Product, error) {
-	var result pb.Product
-	err := c.Coll.FindOne(ctx, bson.D{{Key: "id", Value: in.Value}}).Decode(&result)
-	if err == mongo.ErrNoDocuments {
-		return nil, fmt.Errorf("No document was found with id: %s\n", in.Value)
+	result, err := c.Store.Get(ctx, in.Value)
+	if err != nil {
+		return nil, toStatus(err, "get", in.Value)
+	}
+	if c.variants != nil {
+		result.Variants, err = c.variants.ListVariants(ctx, in.Value)
+		if err != nil {
+			return nil, resourceStatus(err, variantResource, "list", "")
+		}
+	}
+	localize(result, preferredLanguages(ctx))
+	return result, nil
+}
+
+func (c *ProductService) BatchGetProducts(ctx context.Context, req *pb.BatchGetProductsRequest) (*pb.BatchGetProductsResponse, error) {
+	if len(req.Ids) > maxPageSize {
+		return nil, status.Errorf(codes.InvalidArgument, "At most %d products can be requested at once", maxPageSize)
+	}
+	ids := make([]string, 0, len(req.Ids))
+	seen := make(map[string]bool, len(req.Ids))
+	for _, id := range req.Ids {
+		if !seen[id.GetValue()] {
+			seen[id.GetValue()] = true
+			ids = append(ids, id.GetValue())
+		}
+	}
+	found, err := c.Store.GetMany(ctx, ids)
+	if err != nil {
+		return nil, toStatus(err, "get", "")
+	}
+	byID := make(map[string]*pb.Product, len(found))
+	for _, p := range found {
+		byID[p.Id] = p
 	}
+	prefs := preferredLanguages(ctx)
+	res := &pb.BatchGetProductsResponse{}
+	for _, id := range ids {
+		p, ok := byID[id]
+		if !ok {
+			res.MissingIds = append(res.MissingIds, id)
+			continue
+		}
+		localize(p, prefs)
+		res.Products = append(res.Products, p)
+	}
+	return res, nil
+}
+
+// productID returns the id of a new product: the id sent by the client if
+// client ids are accepted, a generated one otherwise. Malformed client ids
+// are InvalidArgument.
+func (c *ProductService) productID(req *pb.Product) (string, error) {
+	if req.Id != "" && c.clientIDs {
+		id, err := c.ids.Parse(req.Id)
+		if err != nil {
+			return "", status.Errorf(codes.InvalidArgument, "Invalid product ID %q: %v", req.Id, err)
+		}
+		return id, nil
+	}
+	id, err := c.ids.NewID()
 	if err != nil {
-		panic(err)
+		return "", status.Errorf(codes.Internal, "Error while generating Product ID: %v", err)
 	}
-	return &result, nil
+	return id, nil
 }
 
 func (c *ProductService) AddProduct(ctx context.Context, req *pb.Product) (*pb.ProductID, error) {
 	prod := &pb.Product{
-		Id:          req.Id,
-		Name:        req.Name,
-		Description: req.Description,
+		Name:         req.Name,
+		Description:  req.Description,
+		Translations: req.Translations,
+		Category:     req.Category,
+		Tags:         req.Tags,
 	}
-	out, err := uuid.NewV4()
+	if err := normalizeTranslations(prod); err != nil {
+		return nil, err
+	}
+	if err := c.checkCategory(ctx, prod); err != nil {
+		return nil, err
+	}
+	id, err := c.productID(req)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Error while generating Product ID: %v", err)
+		return nil, err
+	}
+	prod.Id = id
+	key := idempotencyKey(ctx)
+	if key != "" && c.idempotency != nil {
+		hash := requestHash(req, c.clientIDs)
+		rec, claimed, err := c.idempotency.Claim(ctx, IdempotencyRecord{
+			Key:         scopedIdempotencyKey(ctx, key),
+			RequestHash: hash,
+			ProductID:   prod.Id,
+			ExpiresAt:   time.Now().Add(c.idempotencyTTL),
+			Pending:     true,
+		})
+		if err != nil {
+			return nil, toStatus(err, "add", prod.Id)
+		}
+		if !claimed {
+			if rec.RequestHash != hash {
+				return nil, status.Errorf(codes.FailedPrecondition, "Idempotency key %q was already used with a different request", key)
+			}
+			if rec.Pending {
+				return nil, status.Errorf(codes.Aborted, "A request with idempotency key %q is still in progress, retry later", key)
+			}
+			return &pb.ProductID{Value: rec.ProductID}, nil
+		}
+	}
+	var dup *duplicate
+	if c.duplicatePolicy != "" {
+		dup, err = c.duplicateOf(ctx, prod)
+		if dup != nil && c.duplicatePolicy == DuplicatesReject {
+			err = duplicateStatus(dup)
+		}
+	}
+	if err == nil {
+		err = c.Store.Add(ctx, prod)
 	}
-	prod.Id = out.String()
-	_, err = c.Coll.InsertOne(ctx, prod)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Failed to add product: %v", err)
+		if key != "" && c.idempotency != nil {
+			c.idempotency.Release(context.WithoutCancel(ctx), scopedIdempotencyKey(ctx, key))
+		}
+		return nil, toStatus(err, "add", prod.Id)
+	}
+	if key != "" && c.idempotency != nil {
+		if err := c.idempotency.Complete(context.WithoutCancel(ctx), scopedIdempotencyKey(ctx, key)); err != nil {
+			// The product exists; replays get Aborted until the key expires.
+			slog.Warn("Failed to complete idempotency key", "key", key, "err", err)
+		}
 	}
+	if dup != nil {
+		warnDuplicate(ctx, dup)
+	}
+	c.record(ctx, actor(ctx), pb.AuditEvent_CREATE, nil, prod)
 	return &pb.ProductID{Value: prod.Id}, nil
 }
+
+func (c *ProductService) UpdateProduct(ctx context.Context, req *pb.UpdateProductRequest) (*pb.Product, error) {
+	in := req.GetProduct()
+	if in.GetId() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "Product ID is required")
+	}
+	if len(req.GetUpdateMask().GetPaths()) == 0 {
+		prod := proto.Clone(in).(*pb.Product)
+		prod.Variants = nil
+		prod.Price = nil
+		prod.DeleteTime = nil
+		if err := normalizeTranslations(prod); err != nil {
+			return nil, err
+		}
+		if err := c.checkCategory(ctx, prod); err != nil {
+			return nil, err
+		}
+		var before *pb.Product
+		if c.audit != nil {
+			var err error
+			if before, err = c.Store.Get(ctx, in.Id); err != nil {
+				return nil, toStatus(err, "update", in.Id)
+			}
+		}
+		if err := c.Store.Update(ctx, prod); err != nil {
+			return nil, toStatus(err, "update", in.Id)
+		}
+		c.record(ctx, actor(ctx), pb.AuditEvent_UPDATE, before, prod)
+		return prod, nil
+	}
+	return c.modifyProduct(ctx, in.Id, in.Etag, "update", func(p *pb.Product) error {
+		if err := applyFieldMask(p, in, req.UpdateMask); err != nil {
+			return status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		if err := normalizeTranslations(p); err != nil {
+			return err
+		}
+		return c.checkCategory(ctx, p)
+	})
+}
+
+// modifyProduct is a read-modify-write of the product with id guarded by
+// the etag that was read, or by etag if the client sent one. Without an
+// etag from the client a lost race is simply retried. Errors returned by
+// fn are passed on unchanged.
+func (c *ProductService) modifyProduct(ctx context.Context, id, etag, op string, fn func(*pb.Product) error) (*pb.Product, error) {
+	for attempt := 1; ; attempt++ {
+		current, err := c.Store.Get(ctx, id)
+		if err != nil {
+			return nil, toStatus(err, op, id)
+		}
+		before := proto.Clone(current).(*pb.Product)
+		if err := fn(current); err != nil {
+			return nil, err
+		}
+		if etag != "" {
+			current.Etag = etag
+		}
+		err = c.Store.Update(ctx, current)
+		if errors.Is(err, ErrConflict) && etag == "" && attempt < maxUpdateAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, toStatus(err, op, id)
+		}
+		c.record(ctx, actor(ctx), pb.AuditEvent_UPDATE, before, current)
+		return current, nil
+	}
+}
+
+// DeleteProduct only marks the product deleted. It can be restored until
+// PurgeDeleted removes it together with its variants, prices and assets.
+func (c *ProductService) DeleteProduct(ctx context.Context, in *pb.ProductID) (*emptypb.Empty, error) {
+	var before *pb.Product
+	if c.audit != nil {
+		var err error
+		if before, err = c.Store.Get(ctx, in.Value); err != nil {
+			return nil, toStatus(err, "delete", in.Value)
+		}
+	}
+	if err := c.Store.Delete(ctx, in.Value); err != nil {
+		return nil, toStatus(err, "delete", in.Value)
+	}
+	if before != nil {
+		after := proto.Clone(before).(*pb.Product)
+		after.DeleteTime = toTimestamp(now())
+		c.record(ctx, actor(ctx), pb.AuditEvent_DELETE, before, after)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (c *ProductService) RestoreProduct(ctx context.Context, in *pb.ProductID) (*pb.Product, error) {
+	result, err := c.Store.Restore(ctx, in.Value)
+	if err != nil {
+		return nil, toStatus(err, "restore", in.Value)
+	}
+	// Price changes that became effective while the product was deleted
+	// are still pending. If they fail here the scheduler applies them.
+	if n, err := c.ApplyDuePrices(ctx); err != nil {
+		slog.Warn("Failed to apply due prices of restored product", "product", in.Value, "err", err)
+	} else if n > 0 {
+		if p, err := c.Store.Get(ctx, in.Value); err == nil {
+			result = p
+		}
+	}
+	if c.audit != nil {
+		// Restoring changes nothing but delete_time.
+		before := proto.Clone(result).(*pb.Product)
+		before.DeleteTime = result.UpdateTime
+		c.record(ctx, actor(ctx), pb.AuditEvent_RESTORE, before, result)
+	}
+	return result, nil
+}
+
+// PurgeDeleted removes the products of all tenants that were deleted more
+// than retention ago, along with their variants, prices and assets. It
+// returns the number of purged products.
+func (c *ProductService) PurgeDeleted(ctx context.Context, retention time.Duration) (int, error) {
+	purged, err := c.Store.Purge(ctx, now().Add(-retention))
+	for _, p := range purged {
+		tctx := WithTenant(ctx, p.Tenant)
+		if c.variants != nil {
+			if err := c.variants.DeleteProductVariants(tctx, p.Product.Id); err != nil {
+				return len(purged), err
+			}
+		}
+		if c.prices != nil {
+			if err := c.prices.DeleteProductPrices(tctx, p.Product.Id); err != nil {
+				return len(purged), err
+			}
+		}
+		if c.blobs != nil {
+			if err := c.deleteAssets(tctx, p.Product.Id); err != nil {
+				return len(purged), err
+			}
+		}
+		c.record(tctx, SystemActor, pb.AuditEvent_PURGE, p.Product, p.Product)
+	}
+	return len(purged), err
+}
+
+func (c *ProductService) ListProducts(ctx context.Context, req *pb.ListProductsRequest) (*pb.ListProductsResponse, error) {
+	token, err := decodePageToken(req.PageToken)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid page token: %v", err)
+	}
+	filter, err := newProductFilter(req.Category, req.Tags)
+	if err != nil {
+		return nil, err
+	}
+	filter.ShowDeleted = req.ShowDeleted
+	size := pageSize(req.PageSize)
+	products, err := c.Store.List(ctx, filter, token.After, size+1)
+	if err != nil {
+		return nil, toStatus(err, "list", "")
+	}
+	res := &pb.ListProductsResponse{Products: products}
+	if len(products) > size {
+		res.Products = products[:size]
+		res.NextPageToken = encodePageToken(pageToken{After: products[size-1].Id})
+	}
+	res.CategoryFacets, res.TagFacets, err = c.Store.Facets(ctx, filter)
+	if err != nil {
+		return nil, toStatus(err, "list", "")
+	}
+	return res, nil
+}
+
+func (c *ProductService) ExportProducts(_ *emptypb.Empty, stream pb.ProductInfo_ExportProductsServer) error {
+	ctx := stream.Context()
+	after := ""
+	for {
+		products, err := c.Store.List(ctx, ProductFilter{}, after, exportBatchSize)
+		if err != nil {
+			return toStatus(err, "list", "")
+		}
+		for _, p := range products {
+			if err := stream.Send(p); err != nil {
+				return err
+			}
+		}
+		if len(products) < exportBatchSize {
+			return nil
+		}
+		after = products[len(products)-1].Id
+	}
+}
+
+func (c *ProductService) ImportProducts(stream pb.ProductInfo_ImportProductsServer) error {
+	ctx := stream.Context()
+	summary := &pb.ImportProductsSummary{}
+	batch := make([]*pb.Product, 0, importBatchSize)
+	// rows holds the row number of each product in batch; rejected rows
+	// never reach a batch.
+	rows := make([]int32, 0, importBatchSize)
+	fail := func(row int32, name string, err error) {
+		summary.Failed++
+		summary.Errors = append(summary.Errors, &pb.ImportError{
+			Row:     row,
+			Name:    name,
+			Message: status.Convert(err).Message(),
+		})
+	}
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		errs, err := c.Store.AddMany(ctx, batch)
+		if err != nil {
+			return toStatus(err, "import", "")
+		}
+		for i, err := range errs {
+			if err == nil {
+				summary.Imported++
+				c.record(ctx, actor(ctx), pb.AuditEvent_CREATE, nil, batch[i])
+				continue
+			}
+			fail(rows[i], batch[i].Name, toStatus(err, "import", batch[i].Id))
+		}
+		batch, rows = batch[:0], rows[:0]
+		return nil
+	}
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			if err := flush(); err != nil {
+				return err
+			}
+			sort.SliceStable(summary.Errors, func(i, j int) bool {
+				return summary.Errors[i].Row < summary.Errors[j].Row
+			})
+			return stream.SendAndClose(summary)
+		}
+		if err != nil {
+			return err
+		}
+		summary.Received++
+		id, err := c.productID(req)
+		if status.Code(err) == codes.InvalidArgument {
+			fail(summary.Received, req.Name, err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		prod := &pb.Product{
+			Id:           id,
+			Name:         req.Name,
+			Description:  req.Description,
+			Translations: req.Translations,
+			Category:     req.Category,
+			Tags:         req.Tags,
+		}
+		if err := normalizeTranslations(prod); err != nil {
+			fail(summary.Received, req.Name, err)
+			continue
+		}
+		if err := c.checkCategory(ctx, prod); err != nil {
+			fail(summary.Received, req.Name, err)
+			continue
+		}
+		batch = append(batch, prod)
+		rows = append(rows, summary.Received)
+		if len(batch) == importBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (c *ProductService) WatchProducts(req *pb.WatchProductsRequest, stream pb.ProductInfo_WatchProductsServer) error {
+	if err := c.Store.Watch(stream.Context(), req.ResumeToken, stream.Send); err != nil {
+		return toStatus(err, "watch", "")
+	}
+	return nil
+}
+
+func (c *ProductService) SearchProducts(ctx context.Context, req *pb.SearchProductsRequest) (*pb.SearchProductsResponse, error) {
+	if len(tokenize(req.Query)) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Search query is empty")
+	}
+	filter, err := newProductFilter(req.Category, req.Tags)
+	if err != nil {
+		return nil, err
+	}
+	// Facets are counted over more matches than one page holds.
+	results, err := c.Store.Search(ctx, req.Query, req.Prefix, filter, maxPageSize)
+	if err != nil {
+		return nil, toStatus(err, "search", "")
+	}
+	counter := newFacetCounter()
+	for _, r := range results {
+		counter.add(r.Product)
+	}
+	res := &pb.SearchProductsResponse{}
+	res.CategoryFacets, res.TagFacets = counter.result()
+	if size := pageSize(req.PageSize); len(results) > size {
+		results = results[:size]
+	}
+	prefs := preferredLanguages(ctx)
+	for _, r := range results {
+		localize(r.Product, prefs)
+	}
+	res.Results = results
+	return res, nil
+}
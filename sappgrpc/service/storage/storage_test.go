@@ -0,0 +1,320 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	pb "service/sappgrpc"
+
+	epb "google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func tenantContext(tenant string) context.Context {
+	return WithTenant(context.Background(), tenant)
+}
+
+// wantStatus fails t unless err is a status with code and, if reason is
+// not empty, an ErrorInfo with reason.
+func wantStatus(t *testing.T, err error, code codes.Code, reason string) {
+	t.Helper()
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != code {
+		t.Fatalf("got error %v, want code %v", err, code)
+	}
+	if reason == "" {
+		return
+	}
+	for _, d := range st.Details() {
+		if info, ok := d.(*epb.ErrorInfo); ok {
+			if info.Reason != reason {
+				t.Fatalf("got reason %q, want %q", info.Reason, reason)
+			}
+			return
+		}
+	}
+	t.Fatalf("status %v has no ErrorInfo", st)
+}
+
+func TestListProductsCursorStability(t *testing.T) {
+	m := NewMemoryStore()
+	c := NewProductService(m)
+	ctx := tenantContext("acme")
+	for _, id := range []string{"b", "d", "f", "h", "j"} {
+		if err := m.Add(ctx, &pb.Product{Id: id, Name: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	token := ""
+	for page := 0; ; page++ {
+		res, err := c.ListProducts(ctx, &pb.ListProductsRequest{PageSize: 2, PageToken: token})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range res.Products {
+			got = append(got, p.Id)
+		}
+		if page == 0 {
+			// Writes behind and ahead of the cursor must neither repeat
+			// nor hide the products that were already there.
+			for _, id := range []string{"a", "e"} {
+				if err := m.Add(ctx, &pb.Product{Id: id, Name: id}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := c.DeleteProduct(ctx, &pb.ProductID{Value: "f"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if res.NextPageToken == "" {
+			break
+		}
+		token = res.NextPageToken
+	}
+
+	want := []string{"b", "d", "e", "h", "j"}
+	if len(got) != len(want) {
+		t.Fatalf("got ids %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got ids %v, want %v", got, want)
+		}
+	}
+}
+
+func TestListProductsInvalidPageToken(t *testing.T) {
+	c := NewProductService(NewMemoryStore())
+	_, err := c.ListProducts(tenantContext("acme"), &pb.ListProductsRequest{PageToken: "not a token"})
+	wantStatus(t, err, codes.InvalidArgument, "")
+}
+
+func TestAddProductIdempotency(t *testing.T) {
+	m := NewMemoryStore()
+	c := NewProductService(m, WithIdempotency(m, DefaultIdempotencyTTL))
+	ctx := metadata.NewIncomingContext(tenantContext("acme"), metadata.Pairs(idempotencyKeyHeader, "key-1"))
+
+	first, err := c.AddProduct(ctx, &pb.Product{Name: "Kettle"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay, err := c.AddProduct(ctx, &pb.Product{Name: "Kettle"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replay.Value != first.Value {
+		t.Fatalf("replay returned %s, want %s", replay.Value, first.Value)
+	}
+	products, err := m.List(tenantContext("acme"), ProductFilter{}, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(products) != 1 {
+		t.Fatalf("got %d products, want 1", len(products))
+	}
+
+	_, err = c.AddProduct(ctx, &pb.Product{Name: "Teapot"})
+	wantStatus(t, err, codes.FailedPrecondition, "")
+
+	// Keys are scoped to the tenant.
+	other := metadata.NewIncomingContext(tenantContext("globex"), metadata.Pairs(idempotencyKeyHeader, "key-1"))
+	id, err := c.AddProduct(other, &pb.Product{Name: "Teapot"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.Value == first.Value {
+		t.Fatalf("tenant globex replayed the product of acme")
+	}
+}
+
+func TestAddProductPendingIdempotencyKey(t *testing.T) {
+	m := NewMemoryStore()
+	c := NewProductService(m, WithIdempotency(m, DefaultIdempotencyTTL))
+	ctx := metadata.NewIncomingContext(tenantContext("acme"), metadata.Pairs(idempotencyKeyHeader, "key-1"))
+	req := &pb.Product{Name: "Kettle"}
+
+	// A claim that was never completed stands for a request in flight.
+	_, claimed, err := m.Claim(ctx, IdempotencyRecord{
+		Key:         scopedIdempotencyKey(ctx, "key-1"),
+		RequestHash: requestHash(req, false),
+		ProductID:   "in-flight",
+		ExpiresAt:   now().Add(DefaultIdempotencyTTL),
+		Pending:     true,
+	})
+	if err != nil || !claimed {
+		t.Fatalf("claim: %v, %v", claimed, err)
+	}
+	_, err = c.AddProduct(ctx, req)
+	wantStatus(t, err, codes.Aborted, "")
+}
+
+func TestUpdateProductStaleETag(t *testing.T) {
+	c := NewProductService(NewMemoryStore())
+	ctx := tenantContext("acme")
+	id, err := c.AddProduct(ctx, &pb.Product{Name: "Kettle"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := c.GetProduct(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mask := &fieldmaskpb.FieldMask{Paths: []string{"name"}}
+
+	updated, err := c.UpdateProduct(ctx, &pb.UpdateProductRequest{
+		Product:    &pb.Product{Id: id.Value, Name: "Teapot", Etag: p.Etag},
+		UpdateMask: mask,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Etag == p.Etag {
+		t.Fatalf("etag %q did not change", updated.Etag)
+	}
+
+	_, err = c.UpdateProduct(ctx, &pb.UpdateProductRequest{
+		Product:    &pb.Product{Id: id.Value, Name: "Samovar", Etag: p.Etag},
+		UpdateMask: mask,
+	})
+	wantStatus(t, err, codes.Aborted, "ETAG_MISMATCH")
+}
+
+// conflictStore fails the first conflicts updates like a concurrent
+// writer would.
+type conflictStore struct {
+	*MemoryStore
+	conflicts int
+}
+
+func (s *conflictStore) Update(ctx context.Context, p *pb.Product) error {
+	if s.conflicts > 0 {
+		s.conflicts--
+		return ErrConflict
+	}
+	return s.MemoryStore.Update(ctx, p)
+}
+
+func TestUpdateProductRetriesWithoutETag(t *testing.T) {
+	store := &conflictStore{MemoryStore: NewMemoryStore()}
+	c := NewProductService(store)
+	ctx := tenantContext("acme")
+	id, err := c.AddProduct(ctx, &pb.Product{Name: "Kettle"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mask := &fieldmaskpb.FieldMask{Paths: []string{"name"}}
+
+	store.conflicts = maxUpdateAttempts - 1
+	updated, err := c.UpdateProduct(ctx, &pb.UpdateProductRequest{
+		Product:    &pb.Product{Id: id.Value, Name: "Teapot"},
+		UpdateMask: mask,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Name != "Teapot" {
+		t.Fatalf("got name %q, want Teapot", updated.Name)
+	}
+
+	store.conflicts = maxUpdateAttempts
+	_, err = c.UpdateProduct(ctx, &pb.UpdateProductRequest{
+		Product:    &pb.Product{Id: id.Value, Name: "Samovar"},
+		UpdateMask: mask,
+	})
+	wantStatus(t, err, codes.Aborted, "ETAG_MISMATCH")
+}
+
+type importStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	rows    []*pb.Product
+	summary *pb.ImportProductsSummary
+}
+
+func (s *importStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *importStream) Recv() (*pb.Product, error) {
+	if len(s.rows) == 0 {
+		return nil, io.EOF
+	}
+	p := s.rows[0]
+	s.rows = s.rows[1:]
+	return p, nil
+}
+
+func (s *importStream) SendAndClose(summary *pb.ImportProductsSummary) error {
+	s.summary = summary
+	return nil
+}
+
+func TestImportProducts(t *testing.T) {
+	m := NewMemoryStore()
+	c := NewProductService(m, WithCategories(m))
+	ctx := tenantContext("acme")
+	if err := m.AddCategory(ctx, &pb.Category{Path: "kitchen"}); err != nil {
+		t.Fatal(err)
+	}
+	s := &importStream{ctx: ctx, rows: []*pb.Product{
+		{Name: "Kettle", Category: "kitchen", Tags: []string{" Steel "}, Translations: map[string]*pb.Translation{"de": {Name: "Wasserkocher"}}},
+		{Name: "Lamp", Translations: map[string]*pb.Translation{"not a tag": {}}},
+		{Name: "Sofa", Category: "living-room"},
+		{Name: "Toaster"},
+	}}
+	if err := c.ImportProducts(s); err != nil {
+		t.Fatal(err)
+	}
+	if s.summary.Received != 4 || s.summary.Imported != 2 || s.summary.Failed != 2 {
+		t.Fatalf("got summary %v", s.summary)
+	}
+	for i, row := range []int32{2, 3} {
+		if s.summary.Errors[i].Row != row {
+			t.Fatalf("error %d is for row %d, want %d", i, s.summary.Errors[i].Row, row)
+		}
+	}
+
+	products, err := m.List(ctx, ProductFilter{Category: "kitchen"}, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(products) != 1 {
+		t.Fatalf("got %d kitchen products, want 1", len(products))
+	}
+	p := products[0]
+	if len(p.Tags) != 1 || p.Tags[0] != "steel" || p.Translations["de"].GetName() != "Wasserkocher" {
+		t.Fatalf("imported product lost fields: %v", p)
+	}
+}
+
+func TestListAuditPagesInIDOrder(t *testing.T) {
+	m := NewMemoryStore()
+	c := NewProductService(m, WithAudit(m))
+	ctx := tenantContext("acme")
+	for _, id := range []string{"3", "1", "2"} {
+		if err := m.AppendAudit(ctx, &pb.AuditEvent{Id: id, ProductId: "p"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	res, err := c.ListProductAuditEvents(ctx, &pb.ListProductAuditEventsRequest{ProductId: "p", PageSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Events) != 2 || res.Events[0].Id != "1" || res.Events[1].Id != "2" {
+		t.Fatalf("got first page %v", res.Events)
+	}
+	res, err = c.ListProductAuditEvents(ctx, &pb.ListProductAuditEventsRequest{ProductId: "p", PageSize: 2, PageToken: res.NextPageToken})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Events) != 1 || res.Events[0].Id != "3" || res.NextPageToken != "" {
+		t.Fatalf("got second page %v", res.Events)
+	}
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	pb "service/sappgrpc"
+)
+
+var (
+	ErrNotFound   = errors.New("product not found")
+	ErrNotDeleted = errors.New("product is not deleted")
+)
+
+// ProductRef names a product of some tenant.
+type ProductRef struct {
+	Tenant string
+	ID     string
+}
+
+// ProductStore is the persistence backend behind ProductService. Every
+// call acts on the catalog of the tenant set on ctx with WithTenant.
+// Ids are unique per tenant. Products of other tenants are not listed,
+// searched or watched, and reading or writing one by id fails with
+// ErrNotFound, so tenants can not tell which ids another one uses. Deleted
+// products are kept until they are purged, but reads and writes treat them
+// as not found unless stated otherwise.
+type ProductStore interface {
+	Add(ctx context.Context, p *pb.Product) error
+	// AddMany inserts products in bulk. The returned slice holds a per-product
+	// error (nil on success) in input order; err is set when the whole batch
+	// failed.
+	AddMany(ctx context.Context, ps []*pb.Product) (errs []error, err error)
+	Get(ctx context.Context, id string) (*pb.Product, error)
+	// GetMany returns the products with the given ids in no particular
+	// order. Ids that do not exist or belong to another tenant are skipped.
+	GetMany(ctx context.Context, ids []string) ([]*pb.Product, error)
+	// Update replaces the stored product with p and refreshes p from the
+	// result. When p.Etag is set the update is only applied if it matches
+	// the stored etag, otherwise ErrConflict is returned.
+	Update(ctx context.Context, p *pb.Product) error
+	// Delete marks the product deleted.
+	Delete(ctx context.Context, id string) error
+	// Restore undoes Delete and returns the restored product. It fails with
+	// ErrNotDeleted for a product that is not deleted.
+	Restore(ctx context.Context, id string) (*pb.Product, error)
+	// Purge removes the products of all tenants that were deleted before
+	// the given time and returns their last state.
+	Purge(ctx context.Context, before time.Time) ([]PurgedProduct, error)
+	// List returns up to limit products matching filter ordered by id,
+	// starting after the given id.
+	List(ctx context.Context, filter ProductFilter, after string, limit int) ([]*pb.Product, error)
+	// Facets counts the products matching filter per category and tag.
+	Facets(ctx context.Context, filter ProductFilter) (categories, tags []*pb.FacetCount, err error)
+	// Search returns up to limit products matching the query terms and
+	// filter, best match first. With prefix set terms also match words
+	// they start.
+	Search(ctx context.Context, query string, prefix bool, filter ProductFilter, limit int) ([]*pb.SearchResult, error)
+	// Watch calls fn for every product change until ctx is done or fn
+	// fails. A non-empty resumeToken continues after the event it was
+	// taken from.
+	Watch(ctx context.Context, resumeToken string, fn func(*pb.ProductEvent) error) error
+	// Ping reports whether the backend is reachable.
+	Ping(ctx context.Context) error
+	Close(ctx context.Context) error
+}
+
+// PurgedProduct is a product removed by ProductStore.Purge.
+type PurgedProduct struct {
+	Tenant  string
+	Product *pb.Product
+}
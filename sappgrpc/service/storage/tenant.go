@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	pb "service/sappgrpc"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const tenantHeader = "tenant-id"
+
+// DefaultTenant owns the products stored before catalogs were split by
+// tenant, see the migrations package.
+const DefaultTenant = "default"
+
+var tenantPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx whose store calls act on the catalog of
+// tenant.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant set by WithTenant.
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// ValidTenant reports whether tenant may be used as a tenant id.
+func ValidTenant(tenant string) bool {
+	return tenantPattern.MatchString(tenant)
+}
+
+// tenantFromMetadata resolves the tenant of an incoming ProductInfo call
+// from its tenant-id header. Calls without the header act on
+// defaultTenant, unless it is empty.
+func tenantFromMetadata(ctx context.Context, defaultTenant string) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	v := md.Get(tenantHeader)
+	if len(v) == 0 {
+		if defaultTenant != "" {
+			return defaultTenant, nil
+		}
+		return "", status.Errorf(codes.InvalidArgument, "The %s metadata header is required", tenantHeader)
+	}
+	if !ValidTenant(v[0]) {
+		return "", status.Errorf(codes.InvalidArgument, "Invalid tenant id: %q", v[0])
+	}
+	return v[0], nil
+}
+
+func isProductInfoMethod(method string) bool {
+	return strings.HasPrefix(method, "/"+pb.ProductInfo_ServiceDesc.ServiceName+"/")
+}
+
+// UnaryTenantInterceptor attaches the tenant of each ProductInfo call to its
+// context. Calls without a tenant-id header act on defaultTenant, with an
+// empty defaultTenant the header is required. Other services are passed
+// through untouched.
+func UnaryTenantInterceptor(defaultTenant string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !isProductInfoMethod(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		tenant, err := tenantFromMetadata(ctx, defaultTenant)
+		if err != nil {
+			return nil, err
+		}
+		return handler(WithTenant(ctx, tenant), req)
+	}
+}
+
+// StreamTenantInterceptor is the streaming counterpart of
+// UnaryTenantInterceptor.
+func StreamTenantInterceptor(defaultTenant string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !isProductInfoMethod(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		tenant, err := tenantFromMetadata(ss.Context(), defaultTenant)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &tenantStream{ServerStream: ss, ctx: WithTenant(ss.Context(), tenant)})
+	}
+}
+
+type tenantStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tenantStream) Context() context.Context {
+	return s.ctx
+}
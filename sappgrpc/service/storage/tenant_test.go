@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "service/sappgrpc"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestProductIDsAreUniquePerTenant(t *testing.T) {
+	m := NewMemoryStore()
+	c := NewProductService(m)
+	acme, globex := tenantContext("acme"), tenantContext("globex")
+	addProducts(t, m, acme, "a", "b")
+	addProducts(t, m, globex, "a")
+	if err := m.Add(acme, &pb.Product{Id: "a"}); !errors.Is(err, ErrAlreadyExists) {
+		t.Fatalf("got %v adding a twice, want ErrAlreadyExists", err)
+	}
+
+	// Products of another tenant look like missing ones.
+	for _, id := range []string{"b", "missing"} {
+		_, err := c.GetProduct(globex, &pb.ProductID{Value: id})
+		wantStatus(t, err, codes.NotFound, "PRODUCT_NOT_FOUND")
+		_, err = c.DeleteProduct(globex, &pb.ProductID{Value: id})
+		wantStatus(t, err, codes.NotFound, "PRODUCT_NOT_FOUND")
+	}
+	if err := m.Update(globex, &pb.Product{Id: "b", Name: "Taken"}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("got %v updating the product of another tenant, want ErrNotFound", err)
+	}
+
+	if _, err := c.DeleteProduct(globex, &pb.ProductID{Value: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	p, err := m.Get(acme, "a")
+	if err != nil {
+		t.Fatalf("got %v for a of acme after globex deleted its a", err)
+	}
+	if p.Name != "Product a" {
+		t.Fatalf("got %q for a of acme", p.Name)
+	}
+	if _, err := m.Restore(acme, "a"); !errors.Is(err, ErrNotDeleted) {
+		t.Fatalf("got %v restoring a of acme, want ErrNotDeleted", err)
+	}
+	list, err := m.List(acme, ProductFilter{}, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("got %d products of acme, want 2", len(list))
+	}
+	wantIDs(t, "search acme", searchIDs(t, m, "acme", "product", false), "a", "b")
+	wantIDs(t, "search globex", searchIDs(t, m, "globex", "product", false))
+}
+
+func TestTenantInterceptor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/" + pb.ProductInfo_ServiceDesc.ServiceName + "/GetProduct"}
+	call := func(defaultTenant string, md metadata.MD, info *grpc.UnaryServerInfo) (string, error) {
+		ctx := metadata.NewIncomingContext(context.Background(), md)
+		got, err := UnaryTenantInterceptor(defaultTenant)(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+			return TenantFromContext(ctx), nil
+		})
+		tenant, _ := got.(string)
+		return tenant, err
+	}
+
+	if tenant, err := call("default", metadata.Pairs(tenantHeader, "acme"), info); err != nil || tenant != "acme" {
+		t.Fatalf("got %q, %v with a header", tenant, err)
+	}
+	if tenant, err := call("default", nil, info); err != nil || tenant != "default" {
+		t.Fatalf("got %q, %v without a header", tenant, err)
+	}
+	_, err := call("", nil, info)
+	wantStatus(t, err, codes.InvalidArgument, "")
+	_, err = call("default", metadata.Pairs(tenantHeader, "a/b"), info)
+	wantStatus(t, err, codes.InvalidArgument, "")
+
+	health := &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}
+	if tenant, err := call("", nil, health); err != nil || tenant != "" {
+		t.Fatalf("got %q, %v for another service", tenant, err)
+	}
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	pb "service/sappgrpc"
+
+	"golang.org/x/text/language"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const acceptLanguageHeader = "accept-language"
+
+// parseLanguage returns the canonical form of a BCP 47 language tag, so
+// "en-us" and "en-US" name the same translation.
+func parseLanguage(tag string) (string, error) {
+	t, err := language.Parse(tag)
+	if err != nil || t == language.Und {
+		return "", status.Errorf(codes.InvalidArgument, "Invalid language tag: %q", tag)
+	}
+	return t.String(), nil
+}
+
+// normalizeTranslations canonicalizes the language tags of the
+// translations of p and clears the output only language field.
+func normalizeTranslations(p *pb.Product) error {
+	p.Language = ""
+	if len(p.Translations) == 0 {
+		return nil
+	}
+	ts := make(map[string]*pb.Translation, len(p.Translations))
+	for tag, t := range p.Translations {
+		key, err := parseLanguage(tag)
+		if err != nil {
+			return err
+		}
+		if t == nil {
+			t = &pb.Translation{}
+		}
+		ts[key] = t
+	}
+	p.Translations = ts
+	return nil
+}
+
+// preferredLanguages returns the language preferences of the
+// accept-language request header, best first.
+func preferredLanguages(ctx context.Context) []language.Tag {
+	md, _ := metadata.FromIncomingContext(ctx)
+	v := md.Get(acceptLanguageHeader)
+	if len(v) == 0 {
+		return nil
+	}
+	tags, _, err := language.ParseAcceptLanguage(strings.Join(v, ","))
+	if err != nil {
+		return nil
+	}
+	return tags
+}
+
+// localize replaces name and description of p with its translation best
+// matching prefs. Matching follows the usual fallback chains, e.g.
+// de-CH falls back to de. Without a match the untranslated text is kept,
+// and so are fields the chosen translation leaves empty.
+func localize(p *pb.Product, prefs []language.Tag) {
+	if len(prefs) == 0 || len(p.Translations) == 0 {
+		return
+	}
+	// The first supported tag is the fallback, it stands for the
+	// untranslated text.
+	tags := make([]string, 0, len(p.Translations))
+	for tag := range p.Translations {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	keys := []string{""}
+	supported := []language.Tag{language.Und}
+	for _, tag := range tags {
+		t, err := language.Parse(tag)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, tag)
+		supported = append(supported, t)
+	}
+	_, i, conf := language.NewMatcher(supported).Match(prefs...)
+	if i == 0 || conf == language.No {
+		return
+	}
+	t := p.Translations[keys[i]]
+	if t.Name != "" {
+		p.Name = t.Name
+	}
+	if t.Description != "" {
+		p.Description = t.Description
+	}
+	p.Language = keys[i]
+}
+
+func (c *ProductService) AddProductTranslation(ctx context.Context, req *pb.AddProductTranslationRequest) (*pb.Product, error) {
+	tag, err := parseLanguage(req.Language)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetTranslation().GetName() == "" && req.GetTranslation().GetDescription() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "Translation is empty")
+	}
+	return c.modifyProduct(ctx, req.ProductId, req.Etag, "translate", func(p *pb.Product) error {
+		if p.Translations == nil {
+			p.Translations = make(map[string]*pb.Translation)
+		}
+		p.Translations[tag] = req.Translation
+		return nil
+	})
+}
+
+func (c *ProductService) RemoveProductTranslation(ctx context.Context, req *pb.RemoveProductTranslationRequest) (*pb.Product, error) {
+	tag, err := parseLanguage(req.Language)
+	if err != nil {
+		return nil, err
+	}
+	return c.modifyProduct(ctx, req.ProductId, req.Etag, "translate", func(p *pb.Product) error {
+		if _, ok := p.Translations[tag]; !ok {
+			return status.Errorf(codes.NotFound, "Product %s has no %s translation", req.ProductId, tag)
+		}
+		delete(p.Translations, tag)
+		return nil
+	})
+}
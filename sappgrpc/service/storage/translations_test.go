@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"testing"
+
+	pb "service/sappgrpc"
+
+	"golang.org/x/text/language"
+)
+
+func TestLocalize(t *testing.T) {
+	for _, tt := range []struct {
+		prefs    []string
+		name     string
+		desc     string
+		language string
+	}{
+		{nil, "Kettle", "Boils water", ""},
+		{[]string{"de"}, "Wasserkocher", "Kocht Wasser", "de"},
+		{[]string{"de-CH"}, "Wasserkocher", "Kocht Wasser", "de"},
+		{[]string{"ja", "fr"}, "Bouilloire", "Boils water", "fr"},
+		{[]string{"ja"}, "Kettle", "Boils water", ""},
+	} {
+		p := &pb.Product{
+			Name:        "Kettle",
+			Description: "Boils water",
+			Translations: map[string]*pb.Translation{
+				"de": {Name: "Wasserkocher", Description: "Kocht Wasser"},
+				"fr": {Name: "Bouilloire"},
+			},
+		}
+		var prefs []language.Tag
+		for _, s := range tt.prefs {
+			prefs = append(prefs, language.MustParse(s))
+		}
+		localize(p, prefs)
+		if p.Name != tt.name || p.Description != tt.desc || p.Language != tt.language {
+			t.Errorf("prefs %v: got %q, %q, %q, want %q, %q, %q",
+				tt.prefs, p.Name, p.Description, p.Language, tt.name, tt.desc, tt.language)
+		}
+	}
+}
+
+func TestNormalizeTranslations(t *testing.T) {
+	p := &pb.Product{Language: "de", Translations: map[string]*pb.Translation{"DE-at": {Name: "Wasserkocher"}, "fr": nil}}
+	if err := normalizeTranslations(p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Language != "" || p.Translations["de-AT"].GetName() != "Wasserkocher" || p.Translations["fr"] == nil {
+		t.Fatalf("got %v", p)
+	}
+	p = &pb.Product{Translations: map[string]*pb.Translation{"not a tag": {}}}
+	if err := normalizeTranslations(p); err == nil {
+		t.Fatal("invalid language tag was accepted")
+	}
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	pb "service/sappgrpc"
+
+	"github.com/gofrs/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// VariantCollectionName is the MongoDB collection of product variants.
+const VariantCollectionName = "variants"
+
+const maxSKULength = 64
+
+// VariantStore keeps the variants of products. Like ProductStore every call
+// acts on the tenant set on ctx, and variants of other tenants can not be
+// read or written by id.
+type VariantStore interface {
+	// AddVariant fails with ErrSKUExists if the tenant already has a
+	// variant with the same SKU.
+	AddVariant(ctx context.Context, v *pb.Variant) error
+	GetVariant(ctx context.Context, id string) (*pb.Variant, error)
+	// UpdateVariant replaces SKU and attributes of the variant and
+	// refreshes v from the result.
+	UpdateVariant(ctx context.Context, v *pb.Variant) error
+	DeleteVariant(ctx context.Context, id string) error
+	// ListVariants returns the variants of a product ordered by SKU.
+	ListVariants(ctx context.Context, productID string) ([]*pb.Variant, error)
+	// DeleteProductVariants removes all variants of a product.
+	DeleteProductVariants(ctx context.Context, productID string) error
+}
+
+func validateVariant(v *pb.Variant) error {
+	v.Sku = strings.TrimSpace(v.Sku)
+	if v.Sku == "" || len(v.Sku) > maxSKULength || strings.ContainsFunc(v.Sku, unicode.IsSpace) {
+		return status.Errorf(codes.InvalidArgument, "SKU must be 1 to %d characters without spaces", maxSKULength)
+	}
+	for k := range v.Attributes {
+		if k == "" {
+			return status.Errorf(codes.InvalidArgument, "Variant attribute names can not be empty")
+		}
+	}
+	return nil
+}
+
+func (c *ProductService) CreateVariant(ctx context.Context, req *pb.Variant) (*pb.Variant, error) {
+	if c.variants == nil {
+		return nil, status.Errorf(codes.Unimplemented, "Variants are not configured")
+	}
+	v := &pb.Variant{ProductId: req.ProductId, Sku: req.Sku, Attributes: req.Attributes}
+	if err := validateVariant(v); err != nil {
+		return nil, err
+	}
+	if _, err := c.Store.Get(ctx, v.ProductId); err != nil {
+		return nil, toStatus(err, "get", v.ProductId)
+	}
+	out, err := uuid.NewV4()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Error while generating Variant ID: %v", err)
+	}
+	v.Id = out.String()
+	if err := c.variants.AddVariant(ctx, v); err != nil {
+		return nil, resourceStatus(err, variantResource, "create", v.Id)
+	}
+	return v, nil
+}
+
+func (c *ProductService) GetVariant(ctx context.Context, req *pb.VariantID) (*pb.Variant, error) {
+	if c.variants == nil {
+		return nil, status.Errorf(codes.Unimplemented, "Variants are not configured")
+	}
+	v, err := c.variants.GetVariant(ctx, req.Value)
+	if err != nil {
+		return nil, resourceStatus(err, variantResource, "get", req.Value)
+	}
+	return v, nil
+}
+
+// UpdateVariant replaces SKU and attributes. A variant can not be moved to
+// another product.
+func (c *ProductService) UpdateVariant(ctx context.Context, req *pb.Variant) (*pb.Variant, error) {
+	if c.variants == nil {
+		return nil, status.Errorf(codes.Unimplemented, "Variants are not configured")
+	}
+	v := &pb.Variant{Id: req.Id, Sku: req.Sku, Attributes: req.Attributes}
+	if err := validateVariant(v); err != nil {
+		return nil, err
+	}
+	if err := c.variants.UpdateVariant(ctx, v); err != nil {
+		return nil, resourceStatus(err, variantResource, "update", v.Id)
+	}
+	return v, nil
+}
+
+func (c *ProductService) DeleteVariant(ctx context.Context, req *pb.VariantID) (*emptypb.Empty, error) {
+	if c.variants == nil {
+		return nil, status.Errorf(codes.Unimplemented, "Variants are not configured")
+	}
+	if err := c.variants.DeleteVariant(ctx, req.Value); err != nil {
+		return nil, resourceStatus(err, variantResource, "delete", req.Value)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (c *ProductService) ListVariants(ctx context.Context, req *pb.ProductID) (*pb.ListVariantsResponse, error) {
+	if c.variants == nil {
+		return nil, status.Errorf(codes.Unimplemented, "Variants are not configured")
+	}
+	if _, err := c.Store.Get(ctx, req.Value); err != nil {
+		return nil, toStatus(err, "get", req.Value)
+	}
+	vs, err := c.variants.ListVariants(ctx, req.Value)
+	if err != nil {
+		return nil, resourceStatus(err, variantResource, "list", "")
+	}
+	return &pb.ListVariantsResponse{Variants: vs}, nil
+}
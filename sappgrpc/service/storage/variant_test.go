@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	pb "service/sappgrpc"
+
+	"google.golang.org/grpc/codes"
+)
+
+func createVariant(t *testing.T, c *ProductService, ctx context.Context, productID, sku string) *pb.Variant {
+	t.Helper()
+	v, err := c.CreateVariant(ctx, &pb.Variant{ProductId: productID, Sku: sku})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestVariantSKUsAreUniquePerTenant(t *testing.T) {
+	m := NewMemoryStore()
+	c := NewProductService(m, WithVariants(m))
+	acme, globex := tenantContext("acme"), tenantContext("globex")
+	addProducts(t, m, acme, "kettle", "toaster")
+	addProducts(t, m, globex, "kettle")
+
+	createVariant(t, c, acme, "kettle", "K-1")
+	_, err := c.CreateVariant(acme, &pb.Variant{ProductId: "toaster", Sku: " K-1 "})
+	wantStatus(t, err, codes.AlreadyExists, "SKU_ALREADY_EXISTS")
+
+	// Another tenant may use the same SKU.
+	createVariant(t, c, globex, "kettle", "K-1")
+
+	_, err = c.CreateVariant(acme, &pb.Variant{ProductId: "kettle", Sku: "K 2"})
+	wantStatus(t, err, codes.InvalidArgument, "")
+}
+
+func TestUpdateVariantReassignsSKU(t *testing.T) {
+	m := NewMemoryStore()
+	c := NewProductService(m, WithVariants(m))
+	ctx := tenantContext("acme")
+	addProducts(t, m, ctx, "kettle")
+	small := createVariant(t, c, ctx, "kettle", "K-S")
+	large := createVariant(t, c, ctx, "kettle", "K-L")
+
+	_, err := c.UpdateVariant(ctx, &pb.Variant{Id: large.Id, Sku: "K-S"})
+	wantStatus(t, err, codes.AlreadyExists, "SKU_ALREADY_EXISTS")
+
+	// Keeping the own SKU is no conflict.
+	if _, err := c.UpdateVariant(ctx, &pb.Variant{Id: small.Id, Sku: "K-S", Attributes: map[string]string{"size": "s"}}); err != nil {
+		t.Fatal(err)
+	}
+	// A SKU given up by one variant is free for another.
+	if _, err := c.UpdateVariant(ctx, &pb.Variant{Id: small.Id, Sku: "K-XS"}); err != nil {
+		t.Fatal(err)
+	}
+	v, err := c.UpdateVariant(ctx, &pb.Variant{Id: large.Id, Sku: "K-S"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.ProductId != "kettle" || v.CreateTime == nil {
+		t.Fatalf("update lost fields: %v", v)
+	}
+
+	resp, err := c.ListVariants(ctx, &pb.ProductID{Value: "kettle"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var skus []string
+	for _, v := range resp.Variants {
+		skus = append(skus, v.Sku)
+	}
+	wantIDs(t, "skus", skus, "K-S", "K-XS")
+
+	_, err = c.UpdateVariant(tenantContext("globex"), &pb.Variant{Id: small.Id, Sku: "G-1"})
+	wantStatus(t, err, codes.NotFound, "VARIANT_NOT_FOUND")
+}